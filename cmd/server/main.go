@@ -2,170 +2,686 @@
 package main
 
 import (
-	"challenge-order-service/internal/order"
+	"challenge-order-service/internal/config"
+	"challenge-order-service/internal/health"
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/metrics"
+	"challenge-order-service/internal/migrations"
 	"challenge-order-service/internal/order/handler"
 	"challenge-order-service/internal/order/repository"
 	"challenge-order-service/internal/order/service"
+	"challenge-order-service/internal/tracing"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/streadway/amqp" // <-- Pastikan ini 'streadway'
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-// Konteks global untuk klien Redis
-var ctx = context.Background()
-
 func main() {
-	log.Println("Starting Order Service (Fase 4)...")
+	// Konfigurasi: default < file YAML (CONFIG_FILE) < environment variable
+	cfg, err := config.Load(os.Getenv(config.FileEnv))
+	if err != nil {
+		fatal(logging.New(os.Stderr, logging.DefaultConfig()), "failed to load configuration", err)
+	}
 
-	// === 1. KONEKSI DATABASE ===
-	dsn := os.Getenv("DATABASE_URL")
-	if dsn == "" {
-		log.Fatalf("DATABASE_URL environment variable is not set")
+	// Logger JSON terstruktur; juga dipasang sebagai slog.Default() (dan output package log)
+	logger := newLogger(cfg.Log)
+	slog.SetDefault(logger)
+	// Baris CACHE HIT/MISS (level debug) hanya ditulis 1 dari log.cache_sample_every
+	cacheLogger := logging.Sampled(logger, cfg.Log.CacheSampleEvery)
+
+	// Subcommand: order-service migrate up|down [N]|status (hanya butuh konfigurasi database)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := cfg.Database.Validate(); err != nil {
+			fatal(logger, "invalid configuration", err)
+		}
+		os.Exit(runMigrate(logger, cfg.Database, os.Args[2:]))
+	}
+
+	// Semua nilai wajib dan batasnya diperiksa sebelum satu pun koneksi dibuka
+	if err := cfg.Validate(); err != nil {
+		fatal(logger, "invalid configuration", err)
 	}
+	logger.Info("starting order service", slog.String("config_file", os.Getenv(config.FileEnv)))
+
+	// Context root untuk goroutine background (relay outbox, dll.).
+	// Context per request berasal dari gin, bukan dari sini.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// SIGINT / SIGTERM memulai graceful shutdown (lihat langkah 8)
+	signalCtx, stopSignals := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	// Consumer dihentikan terpisah dari goroutine background lain saat shutdown
+	consumerCtx, stopConsumers := context.WithCancel(ctx)
+	defer stopConsumers()
+
+	// === 0. TRACING (OpenTelemetry) ===
+	// OTLP aktif otomatis jika tracing.otlp_endpoint diisi
+	shutdownTracing, err := tracing.Setup(ctx, tracingConfig(cfg.Tracing))
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal(logger, "failed to set up tracing", err)
 	}
-	log.Println("Database connection established.")
+	logger.Info("tracing configured", slog.String("exporter", cfg.Tracing.Exporter))
 
-	log.Println("Running AutoMigration...")
-	db.AutoMigrate(&order.Order{})
+	// === 0b. PROBE & HTTP SERVER ===
+	// Server dijalankan sebelum dependensi siap: /livez langsung 200, sedangkan
+	// /readyz 503 sampai semua tugas startup selesai. Router lengkap (langkah 6)
+	// menggantikan router startup setelah semua komponen siap.
+	checker := health.NewChecker(cfg.Server.ReadinessCheckTimeout)
+	startupDone := checker.StartTask("startup")
+	bootRouter := gin.New()
+	bootRouter.Use(gin.Recovery())
+	bootRouter.GET("/livez", checker.LivezHandler)
+	bootRouter.GET("/readyz", checker.ReadyzHandler)
+	bootRouter.NoRoute(func(c *gin.Context) {
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": health.StatusStarting})
+	})
 
-	// 2. Inisialisasi Cache (Redis)
-	redisHost := os.Getenv("REDIS_HOST")
-	redisPort := os.Getenv("REDIS_PORT")
-	redisAddr := fmt.Sprintf("%s:%s", redisHost, redisPort)
+	var activeHandler atomic.Value // http.Handler
+	activeHandler.Store(http.Handler(bootRouter))
+	server := &http.Server{
+		Addr: cfg.Server.Addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			activeHandler.Load().(http.Handler).ServeHTTP(w, r)
+		}),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+	}
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("HTTP server listening", slog.String("addr", server.Addr))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	// === 1. KONEKSI DATABASE ===
+	db := openDatabase(logger, cfg.Database)
+	if err := db.Use(tracing.GormPlugin{}); err != nil {
+		fatal(logger, "failed to register GORM tracing plugin", err)
+	}
 
+	// Migrasi SQL berversi; replika lain menunggu advisory lock sampai selesai.
+	// MIGRATE_ON_STARTUP=false jika migrasi dijalankan terpisah (order-service migrate up).
+	if cfg.Database.MigrateOnStartup {
+		migrationsDone := checker.StartTask("migrations")
+		if err := migrateUp(ctx, logger, db); err != nil {
+			fatal(logger, "failed to run database migrations", err)
+		}
+		migrationsDone()
+	}
+
+	// 2. Inisialisasi Cache (Redis)
 	rdb := redis.NewClient(&redis.Options{
-		Addr: redisAddr,
+		Addr:     cfg.Redis.Addr(),
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+		PoolSize: cfg.Redis.PoolSize,
 	})
+	rdb.AddHook(tracing.RedisHook{})
 
 	// Tes koneksi Redis
-	if _, err := rdb.Ping(ctx).Result(); err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
+	pingCtx, pingCancel := context.WithTimeout(ctx, cfg.Redis.ConnectTimeout)
+	defer pingCancel()
+	if _, err := rdb.Ping(pingCtx).Result(); err != nil {
+		fatal(logger, "failed to connect to Redis", err)
 	}
-	log.Println("Redis connection established.")
+	logger.Info("redis connection established")
 
 	// 3. Inisialisasi Message Broker (RabbitMQ)
-	amqpURL := os.Getenv("RABBITMQ_URL")
-	conn, err := amqp.Dial(amqpURL)
-	if err != nil {
-		log.Fatalf("Failed to connect to RabbitMQ: %v", err)
-	}
-	defer conn.Close()
+	// ConnectionManager menyambung ulang otomatis jika broker restart,
+	// mendeklarasikan ulang exchange dan menjalankan ulang consumer.
+	// Broker yang belum bisa dihubungi dalam AMQP_CONNECT_TIMEOUT tidak menghentikan
+	// startup: layanan berjalan terdegradasi (/readyz melaporkan rabbitmq gagal,
+	// event tertahan di outbox) sementara manager terus mencoba di background.
+	amqpManager := service.NewConnectionManager(service.ConnectionManagerConfig{
+		URL:         cfg.RabbitMQ.URL,
+		Topology:    declareExchanges(cfg.RabbitMQ.OrdersExchange, cfg.RabbitMQ.ProductEventsExchange),
+		BaseBackoff: cfg.RabbitMQ.ReconnectBaseDelay,
+		MaxBackoff:  cfg.RabbitMQ.ReconnectMaxDelay,
+		Logger:      logger,
+	})
 
-	ch, err := conn.Channel()
-	if err != nil {
-		log.Fatalf("Failed to open RabbitMQ channel: %v", err)
-	}
-	defer ch.Close()
-	log.Println("RabbitMQ connection established.")
-
-	// Deklarasikan exchange
-	err = ch.ExchangeDeclare(
-		"orders_exchange", // name
-		"topic",           // type
-		true,              // durable
-		false,             // auto-deleted
-		false,             // internal
-		false,             // no-wait
-		nil,               // arguments
-	)
-	if err != nil {
-		log.Fatalf("Failed to declare 'orders_exchange': %v", err)
+	connectCtx, connectCancel := context.WithTimeout(signalCtx, cfg.RabbitMQ.ConnectTimeout)
+	if err := amqpManager.Connect(connectCtx); err != nil {
+		logger.Warn("rabbitmq unavailable, starting degraded and reconnecting in background", logging.Err(err))
+	} else {
+		logger.Info("rabbitmq connection established")
 	}
+	connectCancel()
+	go amqpManager.Run(ctx)
 
 	// 4. Setup Listener 'order.created'
-	go startOrderCreatedLogger(ch)
+	// Setiap consumer memakai channel sendiri, terpisah dari channel publish.
+	// Pesan di-ack manual; yang gagal dicoba ulang lewat '<queue>.retry' lalu masuk '<queue>.dlq'.
+	logConsumer := orderCreatedLogger(logger, cfg)
+	amqpManager.StartConsumer(consumerCtx, "order-created-logger", logConsumer.Run)
 
 	// 5. Setup Arsitektur (Repository -> Service -> Handler)
 	orderRepo := repository.NewOrderRepository(db)
 
 	// FIX: Buat concrete implementation untuk 2 interface baru
-	productClient := service.NewProductClientImpl()
-	publisher := service.NewPublisherImpl(ch)
+	// Product client: base URL dari PRODUCT_SERVICE_URL, dengan timeout, retry dan circuit breaker
+	productCfg := productClientConfig(cfg.ProductService)
+	productCfg.Logger, productCfg.CacheLogger = logger, cacheLogger
+	productClient := service.NewProductClientImpl(productCfg)
+	// Publisher memakai pool channel dengan publisher confirms:
+	// Publish baru sukses setelah broker mengirim ack
+	publisher := service.NewPublisherPool(
+		func() (service.AMQPChannel, error) { return amqpManager.Channel() },
+		cfg.RabbitMQ.PublisherPoolSize,
+		cfg.RabbitMQ.ConfirmTimeout,
+	)
+	// Setelah (re)connect, channel lama atau yang belum terbuka di pool diganti
+	amqpManager.OnReconnect(publisher.Reset)
+
+	// Event perubahan produk memperbarui / membuang cache info produk
+	productConsumer := productEventConsumer(logger, cfg, productClient)
+	amqpManager.StartConsumer(consumerCtx, "product-events", productConsumer.Run)
+
+	// Mode asinkron (opsional): POST /orders mengantrekan order dan membalas 202,
+	// lalu BatchWriter menyimpan order secara batch di background.
+	serviceOpts := []service.OrderServiceOption{
+		service.WithLogger(logger),
+		service.WithCacheLogger(cacheLogger),
+		service.WithStockRefreshMargin(cfg.Orders.StockRefreshMargin),
+		service.WithCacheTTL(cfg.Orders.CacheTTL),
+		service.WithOrdersExchange(cfg.RabbitMQ.OrdersExchange),
+	}
+	var batchWriter *service.BatchWriter
+	if cfg.Orders.AsyncMode {
+		batchCfg := batchWriterConfig(cfg.Orders)
+		batchCfg.Logger = logger
+		batchWriter = service.NewBatchWriter(orderRepo, batchCfg, nil)
+		batchWriter.Start()
+		serviceOpts = append(serviceOpts, service.WithBatchWriter(batchWriter))
+	}
+
+	// Semua event order.* dikirim lewat outbox, jadi service tidak butuh publisher
+	orderService := service.NewOrderService(orderRepo, rdb, productClient, serviceOpts...)
+
+	// Hasil reservasi stok dari product-service memfinalisasi order PENDING
+	stockHandler := service.NewStockEventHandler(orderService)
+	stockHandler.SetLogger(logger)
+	stockConsumer := stockResultConsumer(logger, cfg, stockHandler)
+	amqpManager.StartConsumer(consumerCtx, "stock-results", stockConsumer.Run)
 
-	// FIX: Panggil dengan 4 argumen baru
-	// NewOrderService(repo, rdb, publisher, productClient)
-	orderService := service.NewOrderService(orderRepo, rdb, publisher, productClient)
+	// Endpoint admin untuk memeriksa dan me-replay dead-letter queue
+	// (queue event produk per instance tidak punya DLQ)
+	deadLetterHandler := handler.NewDeadLetterHandler(service.NewDeadLetterManager(
+		amqpManager.Channel, logConsumer.Queue(), stockConsumer.Queue()))
 
-	orderHandler := handler.NewOrderHandler(orderService)
+	// Idempotency-Key disimpan di Redis, dengan tabel 'idempotency_keys' sebagai fallback
+	idempotencyStore := service.NewIdempotencyStore(rdb, repository.NewIdempotencyRepository(db))
+	orderHandler := handler.NewOrderHandler(orderService,
+		handler.WithIdempotencyStore(idempotencyStore), handler.WithLogger(logger))
+
+	// Relay outbox mem-publish event 'order.created' yang tersimpan di DB
+	outboxRelay := service.NewOutboxRelay(repository.NewOutboxRepository(db), publisher)
+	outboxRelay.SetLogger(logger)
+	outboxRelay.SetMaxAttempts(cfg.Outbox.MaxAttempts)
+	outboxRelay.SetRetention(cfg.Outbox.Retention)
+	relayCtx, stopRelay := context.WithCancel(ctx)
+	defer stopRelay()
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		outboxRelay.Run(relayCtx)
+	}()
 
 	// 6. Setup Gin Router
-	router := gin.Default()
+	// Log akses gin diganti logging.GinMiddleware (JSON, dengan request_id & trace_id)
+	router := gin.New()
+	// Metrik dicatat paling luar (sebelum Recovery) agar request yang panic
+	// ikut terhitung sebagai 500, begitu juga status dari ErrorHandler
+	router.Use(metrics.GinMiddleware())
+	router.Use(gin.Recovery())
 	router.SetTrustedProxies(nil)
+	// Span server untuk setiap request (melanjutkan header traceparent dari klien)
+	router.Use(otelgin.Middleware("order-service", otelgin.WithFilter(func(r *http.Request) bool {
+		return !isProbePath(r.URL.Path)
+	})))
+	// X-Request-ID + satu baris log per request; dipasang setelah otelgin agar trace_id tersedia
+	router.Use(logging.GinMiddleware(logger, probePaths...))
+	// Error dari handler (c.Error) diubah menjadi respons {"code", "error"} yang seragam
+	router.Use(handler.ErrorHandler())
+
+	// Metrik Prometheus; gauge dibaca langsung dari komponen saat di-scrape
+	metrics.RegisterGaugeFunc("product_client", "cache_entries", "Jumlah produk di cache in-memory.",
+		func() float64 { return float64(productClient.CacheStats().Size) })
+	metrics.RegisterGaugeFunc("product_client", "circuit_open", "1 jika circuit breaker product-service terbuka.",
+		func() float64 { return boolToFloat(productClient.CircuitState() == service.CircuitOpen) })
+	metrics.RegisterGaugeFunc("amqp", "connected", "1 jika koneksi RabbitMQ terbuka.",
+		func() float64 { return boolToFloat(amqpManager.IsConnected()) })
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
 
-	// Rute Health Check
+	// Rute Health Check. /health dipertahankan untuk kompatibilitas; probe
+	// Kubernetes memakai /livez (proses hidup) dan /readyz (dependensi siap).
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
+	router.GET("/livez", checker.LivezHandler)
+	router.GET("/readyz", checker.ReadyzHandler)
+	registerReadinessChecks(checker, db, rdb, amqpManager, productClient)
 
 	// Rute Fase 4
 	api := router.Group("/api/v1")
 	{
 		api.POST("/orders", orderHandler.CreateOrder)
-		api.GET("/orders/product/:productid", orderHandler.GetOrdersByProductID)
+		api.GET("/orders/:id", orderHandler.GetOrderByID)
+		api.GET("/orders/product/:productID", orderHandler.GetOrdersByProductID)
+		api.PATCH("/orders/:id/status", orderHandler.UpdateOrderStatus)
+	}
+
+	// Endpoint admin wajib membawa ADMIN_TOKEN; nonaktif jika token tidak diisi
+	if cfg.Server.AdminToken != "" {
+		admin := router.Group("/admin", handler.AdminAuth(cfg.Server.AdminToken))
+		{
+			admin.GET("/dead-letters", deadLetterHandler.ListDeadLetters)
+			admin.GET("/dead-letters/:queue", deadLetterHandler.PeekDeadLetters)
+			admin.POST("/dead-letters/:queue/replay", deadLetterHandler.ReplayDeadLetters)
+
+			// Konfigurasi efektif (password disamarkan); nonaktif kecuali DEBUG_ENDPOINTS=true
+			if cfg.Server.DebugEndpoints {
+				admin.GET("/debug/config", config.DebugHandler(cfg))
+			}
+		}
+	}
+
+	// 7. Router lengkap mulai melayani request; /readyz berubah menjadi 200
+	activeHandler.Store(http.Handler(router))
+	startupDone()
+	logger.Info("order service is running", slog.String("addr", server.Addr))
+
+	select {
+	case <-signalCtx.Done():
+		logger.Info("shutdown signal received, shutting down gracefully")
+	case err := <-serverErr:
+		logger.Error("HTTP server error, shutting down", logging.Err(err))
+	}
+	stopSignals() // sinyal kedua langsung menghentikan proses
+
+	// 8. Graceful shutdown, dalam batas server.shutdown_timeout
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer shutdownCancel()
+
+	// 8a. /readyz langsung 503 agar load balancer berhenti mengirim trafik,
+	// lalu berhenti menerima request dan tunggu handler yang sedang berjalan
+	checker.Drain()
+	if delay := cfg.Server.ShutdownDrainDelay; delay > 0 {
+		time.Sleep(delay)
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("HTTP server tidak selesai tepat waktu", logging.Err(err))
+	}
+
+	// 8b. Simpan order yang masih di buffer mode asinkron
+	if batchWriter != nil {
+		batchWriter.Close()
+	}
+
+	// 8c. Hentikan consumer; pesan yang sedang diproses di-nack dan dikembalikan ke queue
+	stopConsumers()
+	if err := amqpManager.WaitConsumers(shutdownCtx); err != nil {
+		logger.Warn("consumer tidak berhenti tepat waktu", logging.Err(err))
 	}
 
-	// Menjalankan server
-	log.Println("Order Service (Fase 4) is running on :8080")
-	router.Run(":8080")
+	// 8d. Hentikan relay outbox lalu kirim sisa event yang sudah jatuh tempo
+	stopRelay()
+	<-relayDone
+	if err := outboxRelay.Flush(shutdownCtx); err != nil {
+		logger.Warn("gagal mengirim sisa outbox event", logging.Err(err))
+	}
+
+	// 8e. Tunggu konfirmasi broker untuk publish yang masih berjalan
+	if err := publisher.Shutdown(shutdownCtx); err != nil {
+		logger.Warn("publisher tidak selesai tepat waktu", logging.Err(err))
+	}
+
+	// 8f. Tutup koneksi: RabbitMQ, Redis, lalu database
+	cancel() // menghentikan ConnectionManager.Run agar tidak menyambung ulang
+	if err := amqpManager.Close(); err != nil {
+		logger.Warn("gagal menutup koneksi RabbitMQ", logging.Err(err))
+	}
+	if err := rdb.Close(); err != nil {
+		logger.Warn("gagal menutup koneksi Redis", logging.Err(err))
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			logger.Warn("gagal menutup koneksi database", logging.Err(err))
+		}
+	}
+	// 8g. Kirim span yang tersisa ke exporter
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		logger.Warn("gagal mengirim sisa trace", logging.Err(err))
+	}
+	logger.Info("order service stopped")
 }
 
-// startOrderCreatedLogger adalah fitur dari soal PDF:
-// "order-service should listen for order.created events and log them"
-func startOrderCreatedLogger(ch *amqp.Channel) {
-	q, err := ch.QueueDeclare(
-		"q.orders.log", // name (buat queue baru untuk logging)
-		true,           // durable
-		false,          // delete when unused
-		false,          // exclusive
-		false,          // no-wait
-		nil,            // arguments
-	)
+// openDatabase membuka koneksi PostgreSQL dan mengatur ukuran pool koneksinya
+func openDatabase(logger *slog.Logger, cfg config.DatabaseConfig) *gorm.DB {
+	db, err := gorm.Open(postgres.Open(cfg.URL), &gorm.Config{})
+	if err != nil {
+		fatal(logger, "failed to connect to database", err)
+	}
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Printf("Failed to declare queue 'q.orders.log': %v", err)
-		return
+		fatal(logger, "failed to access database pool", err)
 	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	logger.Info("database connection established")
+	return db
+}
 
-	// Bind queue ini ke exchange yang sama dengan routing key yang sama
-	err = ch.QueueBind(
-		q.Name,            // queue name
-		"order.created",   // routing key
-		"orders_exchange", // exchange
-		false,
-		nil,
-	)
+// migrateUp menjalankan semua migrasi yang belum dijalankan
+func migrateUp(ctx context.Context, logger *slog.Logger, db *gorm.DB) error {
+	migrator, err := migrations.NewMigrator(db)
 	if err != nil {
-		log.Printf("Failed to bind queue 'q.orders.log': %v", err)
-		return
-	}
-
-	msgs, err := ch.Consume(
-		q.Name, // queue
-		"",     // consumer
-		true,   // auto-ack
-		false,  // exclusive
-		false,  // no-local
-		false,  // no-wait
-		nil,    // args
-	)
+		return err
+	}
+	applied, err := migrator.Up(ctx)
+	for _, m := range applied {
+		logger.Info("migration applied", slog.Int64("version", m.Version), slog.String("name", m.Name))
+	}
+	if err == nil && len(applied) == 0 {
+		logger.Info("database schema is up to date")
+	}
+	return err
+}
+
+// runMigrate menjalankan subcommand migrate dan mengembalikan exit code
+func runMigrate(logger *slog.Logger, dbConfig config.DatabaseConfig, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: order-service migrate up|down [N]|status")
+		return 2
+	}
+
+	ctx := context.Background()
+	db := openDatabase(logger, dbConfig)
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		logger.Error("failed to load migrations", logging.Err(err))
+		return 1
+	}
+
+	switch args[0] {
+	case "up":
+		err = migrateUp(ctx, logger, db)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				fmt.Fprintf(os.Stderr, "invalid step count %q\n", args[1])
+				return 2
+			}
+		}
+		var reverted []migrations.Migration
+		reverted, err = migrator.Down(ctx, steps)
+		for _, m := range reverted {
+			logger.Info("migration reverted", slog.Int64("version", m.Version), slog.String("name", m.Name))
+		}
+	case "status":
+		var statuses []migrations.Status
+		if statuses, err = migrator.Status(ctx); err == nil {
+			printMigrationStatus(statuses)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown migrate command %q (use up, down or status)\n", args[0])
+		return 2
+	}
+
+	if err != nil {
+		logger.Error("migrate "+args[0]+" failed", logging.Err(err))
+		return 1
+	}
+	return 0
+}
+
+// printMigrationStatus menulis tabel status migrasi ke stdout
+func printMigrationStatus(statuses []migrations.Status) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+	w.Flush()
+}
+
+// probePaths tidak di-trace dan tidak dicatat per request (dipanggil sangat sering)
+var probePaths = []string{"/metrics", "/health", "/livez", "/readyz"}
+
+func isProbePath(path string) bool {
+	for _, p := range probePaths {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// registerReadinessChecks mendaftarkan pemeriksaan dependensi untuk /readyz.
+// product-service tidak kritis: saat circuit breaker terbuka instance tetap
+// melayani pembacaan order, jadi statusnya hanya "degraded".
+func registerReadinessChecks(checker *health.Checker, db *gorm.DB, rdb *redis.Client,
+	amqpManager *service.ConnectionManager, productClient *service.ProductClientImpl) {
+	checker.Register("database", true, func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+	checker.Register("redis", true, func(ctx context.Context) error {
+		return rdb.Ping(ctx).Err()
+	})
+	checker.Register("rabbitmq", true, func(ctx context.Context) error {
+		if !amqpManager.IsConnected() {
+			return service.ErrBrokerNotConnected
+		}
+		// Membuka channel memastikan koneksi benar-benar bisa dipakai
+		ch, err := amqpManager.Channel()
+		if err != nil {
+			return err
+		}
+		return ch.Close()
+	})
+	checker.Register("product_service", false, func(ctx context.Context) error {
+		if state := productClient.CircuitState(); state == service.CircuitOpen {
+			return fmt.Errorf("circuit breaker %s", state)
+		}
+		return nil
+	})
+}
+
+// newLogger membuat logger dari log.level (debug/info/warn/error) dan log.format (json/text).
+// Level yang tidak valid memakai info; kesalahannya dilaporkan oleh Config.Validate.
+func newLogger(cfg config.LogConfig) *slog.Logger {
+	return logging.New(os.Stdout, loggingConfig(cfg))
+}
+
+// fatal menulis error lalu menghentikan proses (pengganti log.Fatalf)
+func fatal(logger *slog.Logger, msg string, err error) {
 	if err != nil {
-		log.Printf("Failed to register consumer for 'q.orders.log': %v", err)
-		return
+		logger.Error(msg, logging.Err(err))
+	} else {
+		logger.Error(msg)
 	}
+	os.Exit(1)
+}
 
-	log.Println("Goroutine (Logger) for 'order.created' started...")
-	// Loop selamanya untuk mendengarkan pesan
-	for d := range msgs {
-		log.Printf("[EVENT LOGGER] Received 'order.created' event: %s", d.Body)
+// boolToFloat mengubah bool menjadi nilai gauge 0/1
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
 	}
+	return 0
+}
+
+// declareExchanges mendeklarasikan exchange yang dipakai order-service.
+// Dijalankan setiap kali koneksi ke broker (ulang) dibuat.
+func declareExchanges(ordersExchange, productEventsExchange string) func(ch *amqp.Channel) error {
+	return func(ch *amqp.Channel) error {
+		// Exchange produk milik product-service; deklarasi ulang aman selama parameternya sama
+		for _, exchange := range []string{ordersExchange, productEventsExchange} {
+			err := ch.ExchangeDeclare(
+				exchange, // name
+				"topic",  // type
+				true,     // durable
+				false,    // auto-deleted
+				false,    // internal
+				false,    // no-wait
+				nil,      // arguments
+			)
+			if err != nil {
+				return fmt.Errorf("failed to declare '%s': %w", exchange, err)
+			}
+		}
+		return nil
+	}
+}
+
+// consumerConfig membuat ConsumerConfig dengan kebijakan retry dari konfigurasi
+func consumerConfig(logger *slog.Logger, cfg config.ConsumerConfig, queue, exchange string, routingKeys ...string) service.ConsumerConfig {
+	maxRetries := cfg.MaxRetries
+	if maxRetries == 0 {
+		maxRetries = -1 // 0 di ConsumerConfig berarti default, bukan tanpa retry
+	}
+	return service.ConsumerConfig{
+		Queue:       queue,
+		Exchange:    exchange,
+		RoutingKeys: routingKeys,
+		MaxRetries:  maxRetries,
+		RetryDelay:  cfg.RetryDelay,
+		Prefetch:    cfg.Prefetch,
+		Logger:      logger,
+	}
+}
+
+// loggingConfig memetakan bagian log konfigurasi ke logging.Config
+func loggingConfig(cfg config.LogConfig) logging.Config {
+	logCfg := logging.DefaultConfig()
+	logCfg.Format = cfg.Format
+	logCfg.Level, _ = logging.ParseLevel(cfg.Level)
+	logCfg.CacheSampleEvery = cfg.CacheSampleEvery
+	return logCfg
+}
+
+// tracingConfig memetakan bagian tracing konfigurasi ke tracing.Config
+func tracingConfig(cfg config.TracingConfig) tracing.Config {
+	return tracing.Config{
+		ServiceName:  cfg.ServiceName,
+		Exporter:     cfg.Exporter,
+		FilePath:     cfg.File,
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		SampleRatio:  cfg.SampleRatio,
+	}
+}
+
+// productClientConfig memetakan bagian product_service konfigurasi ke ProductClientConfig (tanpa logger)
+func productClientConfig(cfg config.ProductServiceConfig) service.ProductClientConfig {
+	return service.ProductClientConfig{
+		BaseURL:                 cfg.URL,
+		Timeout:                 cfg.Timeout,
+		MaxRetries:              cfg.MaxRetries,
+		RetryBaseDelay:          cfg.RetryBaseDelay,
+		RetryMaxDelay:           cfg.RetryMaxDelay,
+		BreakerFailureThreshold: cfg.BreakerThreshold,
+		BreakerOpenTimeout:      cfg.BreakerOpenTimeout,
+		CacheSize:               cfg.CacheSize,
+		CacheTTL:                cfg.CacheTTL,
+	}
+}
+
+// batchWriterConfig memetakan bagian orders konfigurasi ke BatchWriterConfig (tanpa logger)
+func batchWriterConfig(cfg config.OrdersConfig) service.BatchWriterConfig {
+	maxRetries := cfg.BatchMaxRetries
+	if maxRetries == 0 {
+		maxRetries = -1 // 0 di BatchWriterConfig berarti default, bukan tanpa retry
+	}
+	return service.BatchWriterConfig{
+		BufferSize:     cfg.BufferSize,
+		BatchSize:      cfg.BatchSize,
+		FlushInterval:  cfg.BatchFlushInterval,
+		Workers:        cfg.BatchWorkers,
+		EnqueueTimeout: cfg.EnqueueTimeout,
+		MaxRetries:     maxRetries,
+		RetryBaseDelay: cfg.BatchRetryDelay,
+	}
+}
+
+// orderCreatedLogger adalah fitur dari soal PDF:
+// "order-service should listen for order.created events and log them"
+func orderCreatedLogger(logger *slog.Logger, cfg config.Config) *service.Consumer {
+	consumerCfg := consumerConfig(logger, cfg.Consumer, cfg.RabbitMQ.Queues.OrderCreatedLog,
+		cfg.RabbitMQ.OrdersExchange, "order.created")
+	return service.NewConsumer(consumerCfg, func(ctx context.Context, d amqp.Delivery) error {
+		var event struct {
+			OrderID   string `json:"orderId"`
+			ProductID string `json:"productId"`
+		}
+		json.Unmarshal(d.Body, &event)
+		logger.InfoContext(ctx, "[EVENT LOGGER] received 'order.created' event",
+			slog.String(logging.KeyOrderID, event.OrderID),
+			slog.String(logging.KeyProductID, event.ProductID),
+			slog.String("body", string(d.Body)))
+		return nil
+	})
+}
+
+// productEventConsumer mendengarkan 'product.updated' dan 'product.deleted'
+// dari product-service agar cek harga & stok di CreateOrder memakai data terbaru.
+// Cache produk ada di memori setiap replika, jadi setiap instance memakai queue
+// exclusive sendiri ('<queue>.<hostname>.<acak>') agar semuanya menerima event.
+func productEventConsumer(logger *slog.Logger, cfg config.Config, productClient *service.ProductClientImpl) *service.Consumer {
+	consumerCfg := consumerConfig(logger, cfg.Consumer, instanceQueueName(cfg.RabbitMQ.Queues.ProductEvents),
+		cfg.RabbitMQ.ProductEventsExchange, service.ProductUpdatedRoutingKey, service.ProductDeletedRoutingKey)
+	consumerCfg.Exclusive = true
+	return service.NewConsumer(consumerCfg, func(ctx context.Context, d amqp.Delivery) error {
+		return productClient.HandleProductEvent(ctx, d.RoutingKey, d.Body)
+	})
+}
+
+// instanceQueueName memberi akhiran unik per proses pada nama queue
+func instanceQueueName(prefix string) string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "order-service"
+	}
+	return fmt.Sprintf("%s.%s.%s", prefix, hostname, uuid.NewString()[:8])
+}
+
+// stockResultConsumer mendengarkan 'stock.reserved' dan 'stock.rejected'
+// lalu memindahkan order ke PROCESSED / FAILED.
+func stockResultConsumer(logger *slog.Logger, cfg config.Config, handler *service.StockEventHandler) *service.Consumer {
+	consumerCfg := consumerConfig(logger, cfg.Consumer, cfg.RabbitMQ.Queues.StockResults,
+		cfg.RabbitMQ.OrdersExchange, service.StockReservedRoutingKey, service.StockRejectedRoutingKey)
+	return service.NewConsumer(consumerCfg, func(ctx context.Context, d amqp.Delivery) error {
+		return handler.Handle(ctx, d.RoutingKey, d.Body)
+	})
 }
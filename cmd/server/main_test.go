@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	"challenge-order-service/internal/config"
+	"challenge-order-service/internal/health"
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/order/service"
+	"challenge-order-service/internal/tracing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// config.Default() menyalin default komponen sebagai nilai biasa; test ini
+// memastikan keduanya tidak menyimpang saat salah satunya diubah
+func TestConfigDefaultsMatchComponentDefaults(t *testing.T) {
+	cfg := config.Default()
+
+	assert.Equal(t, service.DefaultProductClientConfig(), productClientConfig(cfg.ProductService))
+	assert.Equal(t, service.DefaultBatchWriterConfig(), batchWriterConfig(cfg.Orders))
+	assert.Equal(t, logging.DefaultConfig(), loggingConfig(cfg.Log))
+
+	traceCfg := tracing.DefaultConfig()
+	traceCfg.Exporter = ""
+	assert.Equal(t, traceCfg, tracingConfig(cfg.Tracing))
+
+	consumer := service.DefaultConsumerConfig()
+	got := consumerConfig(nil, cfg.Consumer, "", "")
+	assert.Equal(t, consumer.MaxRetries, got.MaxRetries)
+	assert.Equal(t, consumer.RetryDelay, got.RetryDelay)
+	assert.Equal(t, consumer.Prefetch, got.Prefetch)
+
+	assert.Equal(t, health.DefaultCheckTimeout, cfg.Server.ReadinessCheckTimeout)
+	assert.Equal(t, service.DefaultReconnectBaseBackoff, cfg.RabbitMQ.ReconnectBaseDelay)
+	assert.Equal(t, service.DefaultReconnectMaxBackoff, cfg.RabbitMQ.ReconnectMaxDelay)
+	assert.Equal(t, service.DefaultPublisherPoolSize, cfg.RabbitMQ.PublisherPoolSize)
+	assert.Equal(t, service.DefaultConfirmTimeout, cfg.RabbitMQ.ConfirmTimeout)
+	assert.Equal(t, service.DefaultOrdersExchange, cfg.RabbitMQ.OrdersExchange)
+	assert.Equal(t, service.DefaultOrderCacheTTL, cfg.Orders.CacheTTL)
+	assert.Equal(t, service.DefaultStockRefreshMargin, cfg.Orders.StockRefreshMargin)
+	assert.Equal(t, service.DefaultOutboxMaxAttempts, cfg.Outbox.MaxAttempts)
+	assert.Equal(t, service.DefaultOutboxRetention, cfg.Outbox.Retention)
+}
+
+// Konstanta di package config harus sama dengan yang dikenali logging dan tracing
+func TestConfigConstantsMatchComponents(t *testing.T) {
+	assert.Equal(t, logging.FormatJSON, config.LogFormatJSON)
+	assert.Equal(t, logging.FormatText, config.LogFormatText)
+	assert.Equal(t, tracing.ExporterNone, config.TracingExporterNone)
+	assert.Equal(t, tracing.ExporterOTLP, config.TracingExporterOTLP)
+	assert.Equal(t, tracing.ExporterStdout, config.TracingExporterStdout)
+	assert.Equal(t, tracing.ExporterFile, config.TracingExporterFile)
+}
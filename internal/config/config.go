@@ -0,0 +1,546 @@
+// Package config memuat konfigurasi order-service dari file YAML (opsional)
+// dan environment variable, lalu memvalidasinya saat startup.
+//
+// Urutan prioritas: nilai default < file YAML (CONFIG_FILE) < environment variable.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv adalah env var berisi path file konfigurasi YAML
+const FileEnv = "CONFIG_FILE"
+
+// Config adalah seluruh konfigurasi order-service
+type Config struct {
+	Server         ServerConfig         `yaml:"server"`
+	Database       DatabaseConfig       `yaml:"database"`
+	Redis          RedisConfig          `yaml:"redis"`
+	RabbitMQ       RabbitMQConfig       `yaml:"rabbitmq"`
+	ProductService ProductServiceConfig `yaml:"product_service"`
+	Orders         OrdersConfig         `yaml:"orders"`
+	Consumer       ConsumerConfig       `yaml:"consumer"`
+	Outbox         OutboxConfig         `yaml:"outbox"`
+	Log            LogConfig            `yaml:"log"`
+	Tracing        TracingConfig        `yaml:"tracing"`
+}
+
+// ServerConfig mengatur HTTP server, probe dan graceful shutdown
+type ServerConfig struct {
+	Addr                  string        `yaml:"addr" env:"HTTP_ADDR"`
+	ReadHeaderTimeout     time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	ReadinessCheckTimeout time.Duration `yaml:"readiness_check_timeout" env:"READINESS_CHECK_TIMEOUT"`
+	ShutdownTimeout       time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+	ShutdownDrainDelay    time.Duration `yaml:"shutdown_drain_delay" env:"SHUTDOWN_DRAIN_DELAY"`
+	DebugEndpoints        bool          `yaml:"debug_endpoints" env:"DEBUG_ENDPOINTS"` // GET /admin/debug/config, wajib ADMIN_TOKEN
+	AdminToken            string        `yaml:"admin_token" env:"ADMIN_TOKEN"`         // kosong = endpoint /admin/* tidak didaftarkan
+}
+
+// DatabaseConfig mengatur koneksi dan pool PostgreSQL
+type DatabaseConfig struct {
+	URL              string        `yaml:"url" env:"DATABASE_URL"`
+	MigrateOnStartup bool          `yaml:"migrate_on_startup" env:"MIGRATE_ON_STARTUP"`
+	MaxOpenConns     int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"` // 0 = tanpa batas
+	MaxIdleConns     int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime  time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"` // 0 = tanpa batas
+}
+
+// RedisConfig mengatur koneksi Redis
+type RedisConfig struct {
+	Host           string        `yaml:"host" env:"REDIS_HOST"`
+	Port           int           `yaml:"port" env:"REDIS_PORT"`
+	Password       string        `yaml:"password" env:"REDIS_PASSWORD"`
+	DB             int           `yaml:"db" env:"REDIS_DB"`
+	PoolSize       int           `yaml:"pool_size" env:"REDIS_POOL_SIZE"` // 0 = default go-redis (10 per CPU)
+	ConnectTimeout time.Duration `yaml:"connect_timeout" env:"REDIS_CONNECT_TIMEOUT"`
+}
+
+// Addr mengembalikan alamat host:port
+func (c RedisConfig) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// RabbitMQConfig mengatur koneksi broker, pool publisher, exchange dan queue
+type RabbitMQConfig struct {
+	URL                   string        `yaml:"url" env:"RABBITMQ_URL"`
+	ConnectTimeout        time.Duration `yaml:"connect_timeout" env:"AMQP_CONNECT_TIMEOUT"`
+	ReconnectBaseDelay    time.Duration `yaml:"reconnect_base_delay" env:"AMQP_RECONNECT_BASE_DELAY"`
+	ReconnectMaxDelay     time.Duration `yaml:"reconnect_max_delay" env:"AMQP_RECONNECT_MAX_DELAY"`
+	PublisherPoolSize     int           `yaml:"publisher_pool_size" env:"AMQP_PUBLISHER_POOL_SIZE"`
+	ConfirmTimeout        time.Duration `yaml:"confirm_timeout" env:"AMQP_CONFIRM_TIMEOUT"`
+	OrdersExchange        string        `yaml:"orders_exchange" env:"ORDERS_EXCHANGE"`
+	ProductEventsExchange string        `yaml:"product_events_exchange" env:"PRODUCT_EVENTS_EXCHANGE"`
+	Queues                QueueConfig   `yaml:"queues"`
+}
+
+// QueueConfig berisi nama queue milik consumer order-service
+type QueueConfig struct {
+	OrderCreatedLog string `yaml:"order_created_log" env:"QUEUE_ORDER_CREATED_LOG"`
+	ProductEvents   string `yaml:"product_events" env:"QUEUE_PRODUCT_EVENTS"` // prefix; setiap instance memakai queue exclusive sendiri
+	StockResults    string `yaml:"stock_results" env:"QUEUE_STOCK_RESULTS"`
+}
+
+// ProductServiceConfig mengatur klien HTTP product-service dan cache-nya
+type ProductServiceConfig struct {
+	URL                string        `yaml:"url" env:"PRODUCT_SERVICE_URL"`
+	Timeout            time.Duration `yaml:"timeout" env:"PRODUCT_SERVICE_TIMEOUT"`
+	MaxRetries         int           `yaml:"max_retries" env:"PRODUCT_SERVICE_MAX_RETRIES"`
+	RetryBaseDelay     time.Duration `yaml:"retry_base_delay" env:"PRODUCT_SERVICE_RETRY_BASE_DELAY"`
+	RetryMaxDelay      time.Duration `yaml:"retry_max_delay" env:"PRODUCT_SERVICE_RETRY_MAX_DELAY"`
+	BreakerThreshold   int           `yaml:"breaker_threshold" env:"PRODUCT_SERVICE_BREAKER_THRESHOLD"`
+	BreakerOpenTimeout time.Duration `yaml:"breaker_open_timeout" env:"PRODUCT_SERVICE_BREAKER_OPEN_TIMEOUT"`
+	CacheSize          int           `yaml:"cache_size" env:"PRODUCT_CACHE_SIZE"`
+	CacheTTL           time.Duration `yaml:"cache_ttl" env:"PRODUCT_CACHE_TTL"`
+}
+
+// OrdersConfig mengatur cache order dan mode penulisan asinkron
+type OrdersConfig struct {
+	CacheTTL           time.Duration `yaml:"cache_ttl" env:"ORDER_CACHE_TTL"`
+	StockRefreshMargin int           `yaml:"stock_refresh_margin" env:"ORDER_STOCK_REFRESH_MARGIN"`
+	AsyncMode          bool          `yaml:"async_mode" env:"ORDER_ASYNC_MODE"`
+	BufferSize         int           `yaml:"buffer_size" env:"ORDER_BUFFER_SIZE"`
+	BatchSize          int           `yaml:"batch_size" env:"ORDER_BATCH_SIZE"`
+	BatchFlushInterval time.Duration `yaml:"batch_flush_interval" env:"ORDER_BATCH_FLUSH_INTERVAL"`
+	BatchWorkers       int           `yaml:"batch_workers" env:"ORDER_BATCH_WORKERS"`
+	EnqueueTimeout     time.Duration `yaml:"enqueue_timeout" env:"ORDER_ENQUEUE_TIMEOUT"`
+	BatchMaxRetries    int           `yaml:"batch_max_retries" env:"ORDER_BATCH_MAX_RETRIES"` // 0 = tanpa retry
+	BatchRetryDelay    time.Duration `yaml:"batch_retry_delay" env:"ORDER_BATCH_RETRY_DELAY"`
+}
+
+// ConsumerConfig mengatur retry dan prefetch semua consumer
+type ConsumerConfig struct {
+	MaxRetries int           `yaml:"max_retries" env:"CONSUMER_MAX_RETRIES"` // 0 atau negatif = tanpa retry
+	RetryDelay time.Duration `yaml:"retry_delay" env:"CONSUMER_RETRY_DELAY"`
+	Prefetch   int           `yaml:"prefetch" env:"CONSUMER_PREFETCH"`
+}
+
+// OutboxConfig mengatur batas percobaan dan retensi relay outbox
+type OutboxConfig struct {
+	MaxAttempts int           `yaml:"max_attempts" env:"OUTBOX_MAX_ATTEMPTS"` // setelah itu event ditandai FAILED
+	Retention   time.Duration `yaml:"retention" env:"OUTBOX_RETENTION"`       // 0 = event SENT tidak dihapus
+}
+
+// LogConfig mengatur logger
+type LogConfig struct {
+	Level            string `yaml:"level" env:"LOG_LEVEL"`
+	Format           string `yaml:"format" env:"LOG_FORMAT"`
+	CacheSampleEvery int    `yaml:"cache_sample_every" env:"LOG_CACHE_SAMPLE_EVERY"`
+}
+
+// TracingConfig mengatur OpenTelemetry
+type TracingConfig struct {
+	// Exporter kosong = otlp jika OTLPEndpoint diisi, selain itu none
+	Exporter     string  `yaml:"exporter" env:"OTEL_TRACES_EXPORTER"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	ServiceName  string  `yaml:"service_name" env:"OTEL_SERVICE_NAME"`
+	File         string  `yaml:"file" env:"OTEL_TRACES_FILE"`
+	SampleRatio  float64 `yaml:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG"`
+}
+
+// Nilai log.format dan tracing.exporter yang dikenali (sama dengan konstanta
+// di package logging dan tracing; package config tidak mengimpor keduanya)
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+
+	TracingExporterNone   = "none"
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+	TracingExporterFile   = "file"
+)
+
+// Default mengembalikan konfigurasi default. Nilainya disamakan dengan default
+// masing-masing komponen sehingga /admin/debug/config menampilkan nilai yang berlaku;
+// test di cmd/server memastikan keduanya tidak menyimpang.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:                  ":8080",
+			ReadHeaderTimeout:     10 * time.Second,
+			ReadinessCheckTimeout: 2 * time.Second,
+			ShutdownTimeout:       30 * time.Second,
+		},
+		Database: DatabaseConfig{
+			MigrateOnStartup: true,
+			MaxIdleConns:     2, // default database/sql
+		},
+		Redis: RedisConfig{
+			Port:           6379,
+			ConnectTimeout: 5 * time.Second,
+		},
+		RabbitMQ: RabbitMQConfig{
+			ConnectTimeout:        time.Minute,
+			ReconnectBaseDelay:    500 * time.Millisecond,
+			ReconnectMaxDelay:     30 * time.Second,
+			PublisherPoolSize:     4,
+			ConfirmTimeout:        5 * time.Second,
+			OrdersExchange:        "orders_exchange",
+			ProductEventsExchange: "products_exchange",
+			Queues: QueueConfig{
+				OrderCreatedLog: "q.orders.log",
+				ProductEvents:   "q.orders.product_events",
+				StockResults:    "q.orders.stock_results",
+			},
+		},
+		ProductService: ProductServiceConfig{
+			URL:                "http://product-service:3000",
+			Timeout:            2 * time.Second,
+			MaxRetries:         2,
+			RetryBaseDelay:     100 * time.Millisecond,
+			RetryMaxDelay:      time.Second,
+			BreakerThreshold:   5,
+			BreakerOpenTimeout: 30 * time.Second,
+			CacheSize:          10000,
+			CacheTTL:           30 * time.Second,
+		},
+		Orders: OrdersConfig{
+			CacheTTL:           10 * time.Minute,
+			StockRefreshMargin: 5,
+			BufferSize:         10000,
+			BatchSize:          500,
+			BatchFlushInterval: 50 * time.Millisecond,
+			BatchWorkers:       4,
+			EnqueueTimeout:     100 * time.Millisecond,
+			BatchMaxRetries:    3,
+			BatchRetryDelay:    100 * time.Millisecond,
+		},
+		Consumer: ConsumerConfig{
+			MaxRetries: 5,
+			RetryDelay: 5 * time.Second,
+			Prefetch:   10,
+		},
+		Outbox: OutboxConfig{
+			MaxAttempts: 20,
+			Retention:   7 * 24 * time.Hour,
+		},
+		Log: LogConfig{
+			Level:            "info",
+			Format:           LogFormatJSON,
+			CacheSampleEvery: 100,
+		},
+		Tracing: TracingConfig{
+			ServiceName: "order-service",
+			File:        "traces.json",
+			SampleRatio: 1,
+		},
+	}
+}
+
+// Load membaca Default(), menimpanya dengan file YAML di path (jika tidak
+// kosong) lalu dengan environment variable. Nilai belum divalidasi; panggil
+// Validate sebelum dipakai.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	// 1. File YAML; key yang tidak dikenal dianggap salah ketik
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return cfg, fmt.Errorf("gagal membaca file konfigurasi: %w", err)
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+			return cfg, fmt.Errorf("file konfigurasi %s tidak valid: %w", path, err)
+		}
+	}
+
+	// 2. Environment variable (env var kosong dianggap tidak diisi)
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem(), os.LookupEnv); err != nil {
+		return cfg, err
+	}
+
+	// 3. Nilai turunan
+	if cfg.Tracing.Exporter == "" {
+		cfg.Tracing.Exporter = TracingExporterNone
+		if cfg.Tracing.OTLPEndpoint != "" {
+			cfg.Tracing.Exporter = TracingExporterOTLP
+		}
+	}
+	return cfg, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv mengisi field bertag `env` (rekursif ke struct bersarang).
+// Semua nilai yang tidak bisa di-parse dilaporkan sekaligus.
+func applyEnv(v reflect.Value, lookup func(string) (string, bool)) error {
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		field, sf := v.Field(i), v.Type().Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := applyEnv(field, lookup); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		key := sf.Tag.Get("env")
+		if key == "" {
+			continue
+		}
+		raw, ok := lookup(key)
+		if !ok || raw == "" {
+			continue
+		}
+		if err := setField(field, raw); err != nil {
+			errs = append(errs, fmt.Errorf("%s: nilai %q tidak valid: %w", key, raw, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setField(field reflect.Value, raw string) error {
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+	case field.Kind() == reflect.String:
+		field.SetString(raw)
+	case field.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(n))
+	case field.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("tipe %s tidak didukung", field.Type())
+	}
+	return nil
+}
+
+// Validate memeriksa seluruh konfigurasi dan melaporkan semua kesalahan sekaligus
+func (c Config) Validate() error {
+	err := errors.Join(
+		c.Server.validate(),
+		c.Database.Validate(),
+		c.Redis.validate(),
+		c.RabbitMQ.validate(),
+		c.ProductService.validate(),
+		c.Orders.validate(),
+		c.Consumer.validate(),
+		c.Outbox.validate(),
+		c.Log.validate(),
+		c.Tracing.validate(),
+	)
+	if err != nil {
+		return fmt.Errorf("konfigurasi tidak valid:\n%w", err)
+	}
+	return nil
+}
+
+// Validate memeriksa konfigurasi database saja (dipakai subcommand migrate)
+func (c DatabaseConfig) Validate() error {
+	var v validator
+	v.required("database.url", "DATABASE_URL", c.URL)
+	v.nonNegative("database.max_open_conns", int64(c.MaxOpenConns))
+	v.nonNegative("database.max_idle_conns", int64(c.MaxIdleConns))
+	v.nonNegative("database.conn_max_lifetime", int64(c.ConnMaxLifetime))
+	return v.err()
+}
+
+func (c ServerConfig) validate() error {
+	var v validator
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		v.addf("server.addr (HTTP_ADDR) harus berbentuk host:port, mis. \":8080\": %v", err)
+	}
+	v.positive("server.read_header_timeout", int64(c.ReadHeaderTimeout))
+	v.positive("server.readiness_check_timeout", int64(c.ReadinessCheckTimeout))
+	v.positive("server.shutdown_timeout", int64(c.ShutdownTimeout))
+	v.nonNegative("server.shutdown_drain_delay", int64(c.ShutdownDrainDelay))
+	// Konfigurasi efektif hanya boleh dibuka di balik autentikasi admin
+	if c.DebugEndpoints && c.AdminToken == "" {
+		v.addf("server.debug_endpoints (DEBUG_ENDPOINTS) membutuhkan server.admin_token (ADMIN_TOKEN)")
+	}
+	return v.err()
+}
+
+func (c RedisConfig) validate() error {
+	var v validator
+	v.required("redis.host", "REDIS_HOST", c.Host)
+	if c.Port < 1 || c.Port > 65535 {
+		v.addf("redis.port (REDIS_PORT) harus di antara 1 dan 65535, bukan %d", c.Port)
+	}
+	v.nonNegative("redis.db", int64(c.DB))
+	v.nonNegative("redis.pool_size", int64(c.PoolSize))
+	v.positive("redis.connect_timeout", int64(c.ConnectTimeout))
+	return v.err()
+}
+
+func (c RabbitMQConfig) validate() error {
+	var v validator
+	if v.required("rabbitmq.url", "RABBITMQ_URL", c.URL) {
+		v.url("rabbitmq.url", c.URL, "amqp", "amqps")
+	}
+	v.positive("rabbitmq.connect_timeout", int64(c.ConnectTimeout))
+	v.positive("rabbitmq.reconnect_base_delay", int64(c.ReconnectBaseDelay))
+	v.positive("rabbitmq.reconnect_max_delay", int64(c.ReconnectMaxDelay))
+	v.positive("rabbitmq.publisher_pool_size", int64(c.PublisherPoolSize))
+	v.positive("rabbitmq.confirm_timeout", int64(c.ConfirmTimeout))
+	v.required("rabbitmq.orders_exchange", "ORDERS_EXCHANGE", c.OrdersExchange)
+	v.required("rabbitmq.product_events_exchange", "PRODUCT_EVENTS_EXCHANGE", c.ProductEventsExchange)
+	v.required("rabbitmq.queues.order_created_log", "QUEUE_ORDER_CREATED_LOG", c.Queues.OrderCreatedLog)
+	v.required("rabbitmq.queues.product_events", "QUEUE_PRODUCT_EVENTS", c.Queues.ProductEvents)
+	v.required("rabbitmq.queues.stock_results", "QUEUE_STOCK_RESULTS", c.Queues.StockResults)
+
+	// Dua consumer di satu queue akan saling merebut pesan
+	seen := make(map[string]bool)
+	for _, q := range []string{c.Queues.OrderCreatedLog, c.Queues.ProductEvents, c.Queues.StockResults} {
+		if q != "" && seen[q] {
+			v.addf("rabbitmq.queues: queue %q dipakai lebih dari satu consumer", q)
+		}
+		seen[q] = true
+	}
+	return v.err()
+}
+
+func (c ProductServiceConfig) validate() error {
+	var v validator
+	if v.required("product_service.url", "PRODUCT_SERVICE_URL", c.URL) {
+		v.url("product_service.url", c.URL, "http", "https")
+	}
+	v.positive("product_service.timeout", int64(c.Timeout))
+	v.nonNegative("product_service.max_retries", int64(c.MaxRetries))
+	v.positive("product_service.retry_base_delay", int64(c.RetryBaseDelay))
+	v.positive("product_service.retry_max_delay", int64(c.RetryMaxDelay))
+	v.positive("product_service.breaker_threshold", int64(c.BreakerThreshold))
+	v.positive("product_service.breaker_open_timeout", int64(c.BreakerOpenTimeout))
+	v.positive("product_service.cache_size", int64(c.CacheSize))
+	v.positive("product_service.cache_ttl", int64(c.CacheTTL))
+	return v.err()
+}
+
+func (c OrdersConfig) validate() error {
+	var v validator
+	v.positive("orders.cache_ttl", int64(c.CacheTTL))
+	v.nonNegative("orders.stock_refresh_margin", int64(c.StockRefreshMargin))
+	if c.AsyncMode {
+		v.positive("orders.buffer_size", int64(c.BufferSize))
+		v.positive("orders.batch_size", int64(c.BatchSize))
+		v.positive("orders.batch_flush_interval", int64(c.BatchFlushInterval))
+		v.positive("orders.batch_workers", int64(c.BatchWorkers))
+		v.positive("orders.enqueue_timeout", int64(c.EnqueueTimeout))
+		v.nonNegative("orders.batch_max_retries", int64(c.BatchMaxRetries))
+		v.positive("orders.batch_retry_delay", int64(c.BatchRetryDelay))
+	}
+	return v.err()
+}
+
+func (c ConsumerConfig) validate() error {
+	var v validator
+	v.positive("consumer.retry_delay", int64(c.RetryDelay))
+	v.positive("consumer.prefetch", int64(c.Prefetch))
+	return v.err()
+}
+
+func (c OutboxConfig) validate() error {
+	var v validator
+	v.positive("outbox.max_attempts", int64(c.MaxAttempts))
+	v.nonNegative("outbox.retention", int64(c.Retention))
+	return v.err()
+}
+
+func (c LogConfig) validate() error {
+	var v validator
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(c.Level))); err != nil {
+		v.addf("log.level (LOG_LEVEL): level log tidak dikenal: %q", c.Level)
+	}
+	if c.Format != LogFormatJSON && c.Format != LogFormatText {
+		v.addf("log.format (LOG_FORMAT) harus %q atau %q, bukan %q", LogFormatJSON, LogFormatText, c.Format)
+	}
+	v.nonNegative("log.cache_sample_every", int64(c.CacheSampleEvery))
+	return v.err()
+}
+
+func (c TracingConfig) validate() error {
+	var v validator
+	switch c.Exporter {
+	case TracingExporterNone, TracingExporterOTLP, TracingExporterStdout:
+	case TracingExporterFile:
+		v.required("tracing.file", "OTEL_TRACES_FILE", c.File)
+	default:
+		v.addf("tracing.exporter (OTEL_TRACES_EXPORTER) tidak dikenal: %q", c.Exporter)
+	}
+	if c.SampleRatio <= 0 || c.SampleRatio > 1 {
+		v.addf("tracing.sample_ratio (OTEL_TRACES_SAMPLER_ARG) harus di antara 0 (eksklusif) dan 1, bukan %g", c.SampleRatio)
+	}
+	return v.err()
+}
+
+// validator mengumpulkan kesalahan validasi satu bagian konfigurasi
+type validator struct {
+	errs []error
+}
+
+func (v *validator) addf(format string, args ...any) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+// required melaporkan nilai kosong; mengembalikan true jika nilai diisi
+func (v *validator) required(field, env, value string) bool {
+	if value == "" {
+		v.addf("%s (%s) wajib diisi", field, env)
+		return false
+	}
+	return true
+}
+
+func (v *validator) positive(field string, value int64) {
+	if value <= 0 {
+		v.addf("%s harus lebih dari 0", field)
+	}
+}
+
+func (v *validator) nonNegative(field string, value int64) {
+	if value < 0 {
+		v.addf("%s tidak boleh negatif", field)
+	}
+}
+
+// url memeriksa bahwa value adalah URL absolut dengan salah satu scheme
+func (v *validator) url(field, value string, schemes ...string) {
+	u, err := url.Parse(value)
+	if err != nil {
+		// Pesan error url.Parse memuat URL utuh (termasuk password)
+		v.addf("%s bukan URL yang valid", field)
+		return
+	}
+	for _, scheme := range schemes {
+		if u.Scheme == scheme && u.Host != "" {
+			return
+		}
+	}
+	v.addf("%s harus berupa URL absolut dengan scheme %s", field, strings.Join(schemes, " atau "))
+}
+
+func (v *validator) err() error {
+	return errors.Join(v.errs...)
+}
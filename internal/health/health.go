@@ -0,0 +1,196 @@
+// Package health menyediakan probe liveness (/livez) dan readiness (/readyz).
+// Readiness memeriksa setiap dependensi (DB, Redis, RabbitMQ, product-service)
+// dan gagal selama tugas startup (mis. migrasi) belum selesai atau saat shutdown.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Status hasil pemeriksaan
+const (
+	StatusOK           = "ok"
+	StatusFail         = "fail"
+	StatusDegraded     = "degraded"      // hanya dependensi non-kritis yang gagal
+	StatusStarting     = "starting"      // tugas startup belum selesai
+	StatusShuttingDown = "shutting_down" // instance sedang berhenti
+)
+
+// DefaultCheckTimeout adalah batas waktu satu pemeriksaan dependensi
+const DefaultCheckTimeout = 2 * time.Second
+
+// CheckFunc memeriksa satu dependensi; nil = sehat
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult adalah hasil pemeriksaan satu dependensi
+type CheckResult struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report adalah body respons /readyz
+type Report struct {
+	Status  string                 `json:"status"`
+	Checks  map[string]CheckResult `json:"checks"`
+	Pending []string               `json:"pending,omitempty"` // tugas startup yang belum selesai
+}
+
+// check adalah dependensi yang terdaftar
+type check struct {
+	name     string
+	critical bool
+	run      CheckFunc
+}
+
+// Checker menyimpan daftar pemeriksaan dan status startup/shutdown
+type Checker struct {
+	timeout time.Duration
+	started time.Time
+
+	mu       sync.RWMutex
+	checks   []check
+	pending  map[string]struct{}
+	draining bool
+}
+
+// NewChecker membuat Checker; timeout <= 0 memakai DefaultCheckTimeout
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultCheckTimeout
+	}
+	return &Checker{
+		timeout: timeout,
+		started: time.Now(),
+		pending: make(map[string]struct{}),
+	}
+}
+
+// Register menambahkan pemeriksaan. Kegagalan dependensi kritis membuat
+// readiness gagal (503); yang non-kritis hanya menurunkan status ke "degraded".
+func (c *Checker) Register(name string, critical bool, run CheckFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks = append(c.checks, check{name: name, critical: critical, run: run})
+}
+
+// StartTask menandai tugas startup yang sedang berjalan; readiness gagal
+// sampai fungsi yang dikembalikan dipanggil.
+func (c *Checker) StartTask(name string) (done func()) {
+	c.mu.Lock()
+	c.pending[name] = struct{}{}
+	c.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			delete(c.pending, name)
+			c.mu.Unlock()
+		})
+	}
+}
+
+// Drain membuat readiness gagal permanen agar load balancer berhenti
+// mengirim trafik sebelum server dimatikan
+func (c *Checker) Drain() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.draining = true
+}
+
+// Check menjalankan semua pemeriksaan secara paralel, masing-masing dengan timeout
+func (c *Checker) Check(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := append([]check(nil), c.checks...)
+	pending := make([]string, 0, len(c.pending))
+	for name := range c.pending {
+		pending = append(pending, name)
+	}
+	draining := c.draining
+	c.mu.RUnlock()
+	sort.Strings(pending)
+
+	// 1. Jalankan pemeriksaan
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, chk := range checks {
+		wg.Add(1)
+		go func(i int, chk check) {
+			defer wg.Done()
+			results[i] = c.run(ctx, chk)
+		}(i, chk)
+	}
+	wg.Wait()
+
+	// 2. Tentukan status keseluruhan
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks)), Pending: pending}
+	for i, chk := range checks {
+		result := results[i]
+		report.Checks[chk.name] = result
+		if result.Status == StatusOK {
+			continue
+		}
+		if chk.critical {
+			report.Status = StatusFail
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	switch {
+	case draining:
+		report.Status = StatusShuttingDown
+	case len(pending) > 0:
+		report.Status = StatusStarting
+	}
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, chk check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := chk.run(ctx)
+	result := CheckResult{
+		Status:    StatusOK,
+		Critical:  chk.critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Ready bernilai true jika status laporan boleh menerima trafik
+func (r Report) Ready() bool {
+	return r.Status == StatusOK || r.Status == StatusDegraded
+}
+
+// LivezHandler menangani GET /livez: proses hidup dan bisa melayani HTTP.
+// Dependensi tidak diperiksa agar gangguan DB/broker tidak memicu restart.
+func (c *Checker) LivezHandler(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"status":         StatusOK,
+		"uptime_seconds": int64(time.Since(c.started).Seconds()),
+	})
+}
+
+// ReadyzHandler menangani GET /readyz: 200 jika siap menerima trafik, 503 jika tidak
+func (c *Checker) ReadyzHandler(ctx *gin.Context) {
+	report := c.Check(ctx.Request.Context())
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	ctx.JSON(status, report)
+}
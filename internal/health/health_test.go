@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ok(context.Context) error { return nil }
+
+func setupRouter(checker *Checker) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/livez", checker.LivezHandler)
+	router.GET("/readyz", checker.ReadyzHandler)
+	return router
+}
+
+func readyz(t *testing.T, router *gin.Engine) (int, Report) {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+	var report Report
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	return w.Code, report
+}
+
+func TestReadyz_AllHealthy(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Register("database", true, ok)
+	checker.Register("redis", true, ok)
+
+	code, report := readyz(t, setupRouter(checker))
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Equal(t, StatusOK, report.Checks["database"].Status)
+	assert.True(t, report.Checks["database"].Critical)
+}
+
+func TestReadyz_CriticalFailure(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Register("database", true, ok)
+	checker.Register("redis", true, func(context.Context) error { return errors.New("connection refused") })
+
+	code, report := readyz(t, setupRouter(checker))
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusFail, report.Status)
+	assert.Equal(t, "connection refused", report.Checks["redis"].Error)
+}
+
+func TestReadyz_NonCriticalFailureIsDegraded(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Register("database", true, ok)
+	checker.Register("product_service", false, func(context.Context) error { return errors.New("circuit breaker terbuka") })
+
+	code, report := readyz(t, setupRouter(checker))
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusDegraded, report.Status)
+}
+
+func TestReadyz_CheckTimeout(t *testing.T) {
+	checker := NewChecker(10 * time.Millisecond)
+	checker.Register("database", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	code, report := readyz(t, setupRouter(checker))
+
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["database"].Error)
+}
+
+func TestReadyz_StartupTasksAndDrain(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Register("database", true, ok)
+	router := setupRouter(checker)
+
+	// 1. Migrasi masih berjalan: belum siap walau semua dependensi sehat
+	done := checker.StartTask("migrations")
+	code, report := readyz(t, router)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusStarting, report.Status)
+	assert.Equal(t, []string{"migrations"}, report.Pending)
+
+	// 2. Migrasi selesai
+	done()
+	code, _ = readyz(t, router)
+	assert.Equal(t, http.StatusOK, code)
+
+	// 3. Shutdown dimulai
+	checker.Drain()
+	code, report = readyz(t, router)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, StatusShuttingDown, report.Status)
+}
+
+func TestLivez_IgnoresDependencies(t *testing.T) {
+	checker := NewChecker(time.Second)
+	checker.Register("database", true, func(context.Context) error { return errors.New("down") })
+	checker.StartTask("migrations")
+
+	w := httptest.NewRecorder()
+	setupRouter(checker).ServeHTTP(w, httptest.NewRequest("GET", "/livez", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
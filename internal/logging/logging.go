@@ -0,0 +1,164 @@
+// Package logging menyediakan logger terstruktur (log/slog, JSON) order-service.
+// Setiap baris yang ditulis dengan *Context(ctx, ...) otomatis membawa request_id,
+// trace_id dan span_id dari ctx.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Nama field yang dipakai konsisten di semua baris log
+const (
+	KeyRequestID = "request_id"
+	KeyTraceID   = "trace_id"
+	KeySpanID    = "span_id"
+	KeyOrderID   = "order_id"
+	KeyProductID = "product_id"
+	KeyError     = "error"
+)
+
+// Format output logger
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Config mengatur logger
+type Config struct {
+	Level  slog.Level
+	Format string // json (default) atau text
+	// CacheSampleEvery: hanya 1 dari N baris CACHE HIT/MISS yang ditulis (<= 1 berarti semua)
+	CacheSampleEvery int
+}
+
+// DefaultConfig mengembalikan konfigurasi default: level info, JSON, cache 1 dari 100
+func DefaultConfig() Config {
+	return Config{
+		Level:            slog.LevelInfo,
+		Format:           FormatJSON,
+		CacheSampleEvery: 100,
+	}
+}
+
+// ParseLevel mengubah "debug", "info", "warn" atau "error" menjadi slog.Level
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return slog.LevelInfo, fmt.Errorf("level log tidak dikenal: %q", s)
+	}
+	return level, nil
+}
+
+// New membuat logger yang menulis ke w sesuai cfg
+func New(w io.Writer, cfg Config) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: cfg.Level}
+
+	var handler slog.Handler
+	if cfg.Format == FormatText {
+		handler = slog.NewTextHandler(w, opts)
+	} else {
+		handler = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{handler})
+}
+
+// Sampled membungkus logger sehingga hanya 1 dari every baris yang ditulis.
+// Dipakai untuk baris yang sangat sering muncul (mis. CACHE HIT/MISS).
+func Sampled(logger *slog.Logger, every int) *slog.Logger {
+	if every <= 1 {
+		return logger
+	}
+	return slog.New(&samplingHandler{
+		Handler: logger.Handler(),
+		every:   uint64(every),
+		counter: new(atomic.Uint64),
+	})
+}
+
+// OrderID adalah atribut order_id
+func OrderID(id uuid.UUID) slog.Attr {
+	return slog.String(KeyOrderID, id.String())
+}
+
+// ProductID adalah atribut product_id
+func ProductID(id uuid.UUID) slog.Attr {
+	return slog.String(KeyProductID, id.String())
+}
+
+// Err adalah atribut error
+func Err(err error) slog.Attr {
+	return slog.Any(KeyError, err)
+}
+
+// requestIDKey adalah key context untuk request ID
+type requestIDKey struct{}
+
+// WithRequestID menyimpan request ID di ctx
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID mengembalikan request ID dari ctx, atau "" jika tidak ada
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler menambahkan request_id, trace_id dan span_id dari ctx ke setiap baris
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx != nil {
+		if id := RequestID(ctx); id != "" {
+			r.AddAttrs(slog.String(KeyRequestID, id))
+		}
+		if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+			r.AddAttrs(
+				slog.String(KeyTraceID, sc.TraceID().String()),
+				slog.String(KeySpanID, sc.SpanID().String()),
+			)
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// samplingHandler meneruskan 1 dari every baris yang lolos level.
+// counter dibagi dengan turunan WithAttrs/WithGroup.
+type samplingHandler struct {
+	slog.Handler
+	every   uint64
+	counter *atomic.Uint64
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if (h.counter.Add(1)-1)%h.every != 0 {
+		return nil
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), every: h.every, counter: h.counter}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), every: h.every, counter: h.counter}
+}
@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// decodeLines mem-parse setiap baris JSON yang ditulis logger
+func decodeLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	for _, raw := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if raw == "" {
+			continue
+		}
+		var line map[string]any
+		require.NoError(t, json.Unmarshal([]byte(raw), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestNew_AddsRequestAndTraceIDFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, DefaultConfig())
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled,
+	}))
+	ctx = WithRequestID(ctx, "req-1")
+
+	logger.InfoContext(ctx, "order dibuat", slog.String(KeyOrderID, "o-1"))
+
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "req-1", lines[0][KeyRequestID])
+	assert.Equal(t, traceID.String(), lines[0][KeyTraceID])
+	assert.Equal(t, spanID.String(), lines[0][KeySpanID])
+	assert.Equal(t, "o-1", lines[0][KeyOrderID])
+}
+
+func TestNew_RespectsLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, DefaultConfig())
+
+	logger.Debug("tidak ditulis")
+	assert.Empty(t, buf.String())
+}
+
+func TestSampled_WritesOneOfEvery(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.Level = slog.LevelDebug
+	sampled := Sampled(New(&buf, cfg), 3)
+
+	for i := 0; i < 7; i++ {
+		sampled.Debug("CACHE HIT")
+	}
+	// Baris ke-1, ke-4 dan ke-7
+	assert.Len(t, decodeLines(t, &buf), 3)
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("warn")
+	assert.NoError(t, err)
+	assert.Equal(t, slog.LevelWarn, level)
+
+	_, err = ParseLevel("verbose")
+	assert.Error(t, err)
+}
+
+func TestGinMiddleware_RequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	router := gin.New()
+	router.Use(GinMiddleware(New(&buf, DefaultConfig()), "/metrics"))
+
+	var seen string
+	router.GET("/orders/:id", func(c *gin.Context) {
+		seen = RequestID(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+	router.GET("/metrics", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	// 1. Request ID dari klien dipakai ulang
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/orders/1", nil)
+	req.Header.Set(RequestIDHeader, "client-id")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "client-id", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "client-id", seen)
+
+	// 2. Tanpa header: dibuatkan baru
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/orders/2", nil))
+	assert.NotEmpty(t, w.Header().Get(RequestIDHeader))
+	assert.Equal(t, w.Header().Get(RequestIDHeader), seen)
+
+	// 3. Path yang di-skip tetap mendapat header, tapi tidak dicatat
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics", nil))
+
+	lines := decodeLines(t, &buf)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "client-id", lines[0][KeyRequestID])
+	assert.Equal(t, "/orders/:id", lines[0]["route"])
+	assert.Equal(t, float64(http.StatusOK), lines[0]["status"])
+}
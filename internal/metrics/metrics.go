@@ -0,0 +1,167 @@
+// Package metrics berisi metrik Prometheus order-service yang diekspos di /metrics
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "order_service"
+
+// Nama cache untuk label "cache" di CacheRequests
+const (
+	CacheOrdersByProduct = "orders_by_product"
+	CacheOrder           = "order"
+	CacheProductInfo     = "product_info"
+)
+
+var (
+	// HTTPRequests menghitung request per route (template, mis. /api/v1/orders/:id)
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Jumlah request HTTP per method, route dan status.",
+	}, []string{"method", "route", "status"})
+
+	// HTTPRequestDuration mengukur latensi request per route
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latensi request HTTP per method dan route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	// OrdersCreated menghitung hasil POST /orders: created, accepted, atau kode error (lowercase)
+	OrdersCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "orders",
+		Name:      "created_total",
+		Help:      "Hasil pembuatan order per status (created, accepted, insufficient_stock, ...).",
+	}, []string{"status"})
+
+	// OrdersDropped menghitung order mode asinkron yang sudah dibalas 202 tetapi gagal disimpan
+	OrdersDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "orders",
+		Name:      "dropped_total",
+		Help:      "Jumlah order mode asinkron (sudah dibalas 202) yang gagal disimpan setelah semua retry dan dibuang.",
+	})
+
+	// OutboxEventsFailed menghitung event outbox yang ditandai FAILED setelah batas percobaan habis
+	OutboxEventsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "events_failed_total",
+		Help:      "Jumlah event outbox yang berhenti dikirim ulang (status FAILED) per routing key.",
+	}, []string{"routing_key"})
+
+	// OutboxEventsDeleted menghitung event SENT yang dihapus pembersihan retensi
+	OutboxEventsDeleted = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "outbox",
+		Name:      "events_deleted_total",
+		Help:      "Jumlah event outbox SENT yang dihapus karena melewati masa retensi.",
+	})
+
+	// CacheRequests menghitung hit/miss per cache (orders_by_product, order, product_info)
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Jumlah pembacaan cache per cache dan hasil (hit/miss).",
+	}, []string{"cache", "result"})
+
+	// UpstreamRequestDuration mengukur setiap percobaan HTTP ke layanan lain
+	UpstreamRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "upstream",
+		Name:      "request_duration_seconds",
+		Help:      "Latensi percobaan HTTP ke layanan upstream per hasil.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"upstream", "result"})
+
+	// Publishes menghitung publish ke RabbitMQ per hasil konfirmasi broker
+	Publishes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "amqp",
+		Name:      "publishes_total",
+		Help:      "Jumlah publish per exchange, routing key dan hasil (success, nacked, unroutable, timeout, ...).",
+	}, []string{"exchange", "routing_key", "result"})
+
+	// PublishDuration mengukur waktu publish sampai konfirmasi broker diterima
+	PublishDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "amqp",
+		Name:      "publish_duration_seconds",
+		Help:      "Waktu publish sampai konfirmasi broker per exchange.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"exchange"})
+
+	// ConsumedMessages menghitung pesan yang diproses consumer per hasil
+	ConsumedMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "messages_total",
+		Help:      "Jumlah pesan per queue dan hasil (ack, retry, dead_letter, requeue, discarded).",
+	}, []string{"queue", "result"})
+
+	// ConsumerProcessingDuration mengukur lama handler memproses satu pesan
+	ConsumerProcessingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "processing_duration_seconds",
+		Help:      "Lama handler memproses satu pesan per queue.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"queue"})
+
+	// ConsumerLag mengukur umur pesan (sejak di-publish) saat mulai diproses
+	ConsumerLag = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "consumer",
+		Name:      "lag_seconds",
+		Help:      "Selisih waktu publish dan mulai diproses per queue.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300, 900},
+	}, []string{"queue"})
+)
+
+// CacheHit mencatat satu pembacaan cache yang berhasil
+func CacheHit(cache string) {
+	CacheRequests.WithLabelValues(cache, "hit").Inc()
+}
+
+// CacheMiss mencatat satu pembacaan cache yang gagal (data diambil dari sumbernya)
+func CacheMiss(cache string) {
+	CacheRequests.WithLabelValues(cache, "miss").Inc()
+}
+
+// RegisterGaugeFunc mendaftarkan gauge yang nilainya dibaca dari fn setiap kali /metrics di-scrape
+func RegisterGaugeFunc(subsystem, name, help string, fn func() float64) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, fn)
+}
+
+// GinMiddleware mencatat jumlah dan latensi request per route.
+// Request ke path yang tidak terdaftar dicatat sebagai route "unmatched".
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		HTTPRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGinMiddleware_RecordsRouteTemplate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GinMiddleware())
+	router.GET("/orders/:id", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+
+	before := testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/orders/:id", "404"))
+	for _, path := range []string{"/orders/1", "/orders/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	// Path berbeda tercatat di bawah satu route, bukan satu seri per ID
+	assert.Equal(t, before+2, testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/orders/:id", "404")))
+}
+
+func TestGinMiddleware_UnmatchedRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GinMiddleware())
+
+	before := testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "unmatched", "404"))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/nope", nil))
+
+	assert.Equal(t, before+1, testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "unmatched", "404")))
+}
+
+func TestGinMiddleware_CountsRecoveredPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	// Urutan sama dengan cmd/server: metrik di luar Recovery
+	router.Use(GinMiddleware(), gin.Recovery())
+	router.GET("/panic", func(c *gin.Context) { panic("boom") })
+
+	before := testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/panic", "500"))
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+
+	assert.Equal(t, before+1, testutil.ToFloat64(HTTPRequests.WithLabelValues("GET", "/panic", "500")))
+}
+
+func TestCacheHitMiss(t *testing.T) {
+	hits := testutil.ToFloat64(CacheRequests.WithLabelValues(CacheOrder, "hit"))
+	misses := testutil.ToFloat64(CacheRequests.WithLabelValues(CacheOrder, "miss"))
+
+	CacheHit(CacheOrder)
+	CacheMiss(CacheOrder)
+	CacheMiss(CacheOrder)
+
+	assert.Equal(t, hits+1, testutil.ToFloat64(CacheRequests.WithLabelValues(CacheOrder, "hit")))
+	assert.Equal(t, misses+2, testutil.ToFloat64(CacheRequests.WithLabelValues(CacheOrder, "miss")))
+}
@@ -0,0 +1,321 @@
+// Package migrations menjalankan migrasi SQL berversi yang di-embed ke binary.
+// Versi yang sudah dijalankan dicatat di tabel schema_migrations; di PostgreSQL
+// advisory lock memastikan hanya satu replika yang bermigrasi pada satu waktu.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql/*.sql
+var embedded embed.FS
+
+// lockKey adalah key pg_advisory_lock untuk migrasi order-service
+const lockKey int64 = 7_244_011_052
+
+// ErrNoDownMigration dikembalikan jika migrasi yang akan di-rollback tidak punya file .down.sql
+var ErrNoDownMigration = errors.New("migrasi tidak punya file down")
+
+// fileName: <versi>_<nama>.<up|down>.sql, mis. 0001_create_orders.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration adalah satu versi skema
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status adalah kondisi satu migrasi di database
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time // nil = belum dijalankan
+}
+
+// schemaMigration adalah baris tabel schema_migrations
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// SQL migrasi ditulis untuk PostgreSQL. Untuk SQLite (tes) beberapa tipe
+// diganti agar driver mengenali kolom waktu dan biner.
+var dialectReplacers = map[string]*strings.Replacer{
+	"sqlite": strings.NewReplacer("TIMESTAMPTZ", "TIMESTAMP", "BYTEA", "BLOB"),
+}
+
+// conditionalColumnSQL cocok dengan ALTER TABLE ... ADD COLUMN IF NOT EXISTS dan
+// DROP COLUMN IF EXISTS, yang tidak didukung SQLite
+var conditionalColumnSQL = regexp.MustCompile(`(?i)^ALTER TABLE (\w+) (ADD|DROP) COLUMN (IF (?:NOT )?EXISTS )(\w+)`)
+
+// Migrator menjalankan migrasi terhadap satu database
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator membuat Migrator dengan migrasi yang di-embed di binary
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	sub, err := fs.Sub(embedded, "sql")
+	if err != nil {
+		return nil, err
+	}
+	return newMigrator(db, sub)
+}
+
+func newMigrator(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load membaca pasangan file up/down dari fsys, diurutkan berdasarkan versi
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca direktori migrasi: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("nama file migrasi tidak valid: %s", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("versi migrasi %d dipakai oleh %q dan %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migrasi %04d_%s tidak punya file up", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up menjalankan semua migrasi yang belum dijalankan, berurutan.
+// Mengembalikan migrasi yang baru saja dijalankan.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		done, err := m.appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(conn, migration, true); err != nil {
+				return err
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down me-rollback steps migrasi terakhir yang sudah dijalankan (terbaru lebih dulu)
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		done, err := m.appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			if strings.TrimSpace(migration.Down) == "" {
+				return fmt.Errorf("%w: %04d_%s", ErrNoDownMigration, migration.Version, migration.Name)
+			}
+			if err := m.apply(conn, migration, false); err != nil {
+				return err
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status mengembalikan semua migrasi beserta waktu dijalankannya
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn := m.db.WithContext(ctx)
+	done, err := m.appliedVersions(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if appliedAt, ok := done[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// withLock menjalankan fn di satu koneksi yang memegang advisory lock
+// (PostgreSQL). Dialek lain tidak dikunci.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if conn.Dialector.Name() == "postgres" {
+			// Replika lain menunggu di sini sampai migrasi selesai
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", lockKey).Error; err != nil {
+				return fmt.Errorf("gagal mengambil lock migrasi: %w", err)
+			}
+			defer conn.WithContext(context.WithoutCancel(ctx)).Exec("SELECT pg_advisory_unlock(?)", lockKey)
+		}
+		return fn(conn)
+	})
+}
+
+// appliedVersions membuat tabel schema_migrations jika belum ada lalu membaca isinya
+func (m *Migrator) appliedVersions(conn *gorm.DB) (map[int64]time.Time, error) {
+	if err := conn.Exec(m.dialect(conn, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    BIGINT PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL
+)`)).Error; err != nil {
+		return nil, fmt.Errorf("gagal membuat tabel schema_migrations: %w", err)
+	}
+
+	var rows []schemaMigration
+	if err := conn.Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("gagal membaca schema_migrations: %w", err)
+	}
+	done := make(map[int64]time.Time, len(rows))
+	for _, row := range rows {
+		done[row.Version] = row.AppliedAt
+	}
+	return done, nil
+}
+
+// apply menjalankan file up/down dan memperbarui schema_migrations dalam satu transaksi
+func (m *Migrator) apply(conn *gorm.DB, migration Migration, up bool) error {
+	body, direction := migration.Up, "up"
+	if !up {
+		body, direction = migration.Down, "down"
+	}
+
+	err := conn.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range splitStatements(m.dialect(tx, body)) {
+			stmt, ok := m.conditionalColumn(tx, stmt)
+			if !ok {
+				continue
+			}
+			if err := tx.Exec(stmt).Error; err != nil {
+				return err
+			}
+		}
+		if up {
+			return tx.Create(&schemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now().UTC(),
+			}).Error
+		}
+		return tx.Delete(&schemaMigration{}, migration.Version).Error
+	})
+	if err != nil {
+		return fmt.Errorf("migrasi %04d_%s (%s) gagal: %w", migration.Version, migration.Name, direction, err)
+	}
+	return nil
+}
+
+// dialect menyesuaikan SQL PostgreSQL dengan dialek database conn
+func (m *Migrator) dialect(conn *gorm.DB, sql string) string {
+	if r, ok := dialectReplacers[conn.Dialector.Name()]; ok {
+		return r.Replace(sql)
+	}
+	return sql
+}
+
+// conditionalColumn menerjemahkan ADD/DROP COLUMN IF [NOT] EXISTS untuk SQLite:
+// klausa IF dibuang dan ok bernilai false jika kolomnya sudah ada (ADD) atau
+// sudah tidak ada (DROP), sehingga statement dilewati. Dialek lain tidak diubah.
+func (m *Migrator) conditionalColumn(tx *gorm.DB, stmt string) (string, bool) {
+	if tx.Dialector.Name() != "sqlite" {
+		return stmt, true
+	}
+	match := conditionalColumnSQL.FindStringSubmatchIndex(stmt)
+	if match == nil {
+		return stmt, true
+	}
+	table, action, column := stmt[match[2]:match[3]], stmt[match[4]:match[5]], stmt[match[8]:match[9]]
+	if tx.Migrator().HasColumn(table, column) == strings.EqualFold(action, "ADD") {
+		return "", false
+	}
+	return stmt[:match[6]] + stmt[match[7]:], true
+}
+
+// splitStatements memecah isi file menjadi statement terpisah (dipisah ';').
+// Baris komentar '--' dibuang; migrasi tidak boleh memakai ';' di dalam string.
+func splitStatements(sql string) []string {
+	var b strings.Builder
+	for _, line := range strings.Split(sql, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "--") {
+			continue
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	var statements []string
+	for _, stmt := range strings.Split(b.String(), ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			statements = append(statements, stmt)
+		}
+	}
+	return statements
+}
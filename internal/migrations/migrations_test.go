@@ -0,0 +1,181 @@
+package migrations
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"challenge-order-service/internal/order"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var ctx = context.Background()
+
+func openTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	// Satu koneksi: setiap koneksi baru ke ":memory:" adalah database kosong
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	return db
+}
+
+func TestMigrator_UpDownStatus(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := NewMigrator(db)
+	require.NoError(t, err)
+
+	// 1. Up menjalankan semua migrasi
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrator.migrations))
+	for _, table := range []string{"orders", "order_items", "outbox_events", "idempotency_keys"} {
+		assert.True(t, db.Migrator().HasTable(table), table)
+	}
+
+	// 2. Up kedua tidak melakukan apa-apa
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.NotNil(t, s.AppliedAt, s.Name)
+	}
+
+	// 3. Down satu langkah me-rollback migrasi terakhir saja
+	reverted, err := migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, "add_order_snapshot_columns", reverted[0].Name)
+	// Down 0006 tidak menghapus kolom yang di database baru dibuat oleh 0001
+	assert.True(t, db.Migrator().HasColumn("orders", "product_name"))
+
+	statuses, err = migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, statuses[len(statuses)-1].AppliedAt)
+
+	// 4. Up lagi hanya menjalankan migrasi yang di-rollback
+	applied, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, 1)
+
+	// 5. Migrasi dengan DROP COLUMN IF EXISTS bisa di-rollback di SQLite
+	reverted, err = migrator.Down(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, reverted, 2)
+	assert.Equal(t, "add_outbox_headers", reverted[1].Name)
+	assert.False(t, db.Migrator().HasColumn("outbox_events", "headers"))
+	assert.True(t, db.Migrator().HasTable("outbox_events"))
+}
+
+func TestMigrator_UpgradesBaselineAutoMigrateSchema(t *testing.T) {
+	db := openTestDB(t)
+
+	// Skema yang dibuat AutoMigrate versi awal: tanpa kolom snapshot produk
+	require.NoError(t, db.Exec(`CREATE TABLE orders (
+    id          UUID PRIMARY KEY,
+    product_id  UUID NOT NULL,
+    total_price DECIMAL(10,2) NOT NULL,
+    status      VARCHAR(50) NOT NULL,
+    created_at  TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+)`).Error)
+	oldID := uuid.New()
+	require.NoError(t, db.Exec("INSERT INTO orders (id, product_id, total_price, status) VALUES (?, ?, ?, ?)",
+		oldID, uuid.New(), 20.0, order.StatusPending).Error)
+
+	migrator, err := NewMigrator(db)
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	for _, column := range []string{"quantity", "unit_price", "product_name"} {
+		assert.True(t, db.Migrator().HasColumn(&order.Order{}, column), column)
+	}
+
+	// Baris lama mendapat nilai default, order baru bisa disimpan dengan snapshot
+	var old order.Order
+	require.NoError(t, db.First(&old, "id = ?", oldID).Error)
+	assert.Equal(t, 0, old.Quantity)
+	assert.Equal(t, "", old.ProductName)
+
+	newOrder := &order.Order{ProductID: uuid.New(), TotalPrice: 30, Quantity: 3, UnitPrice: 10, ProductName: "Kopi"}
+	require.NoError(t, db.Create(newOrder).Error)
+}
+
+func TestMigrator_FailedMigrationIsRolledBack(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := newMigrator(db, fstest.MapFS{
+		"0001_ok.up.sql":     {Data: []byte("CREATE TABLE a (id BIGINT);")},
+		"0002_broken.up.sql": {Data: []byte("CREATE TABLE b (id BIGINT);\nNOT VALID SQL;")},
+	})
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	assert.ErrorContains(t, err, "0002_broken")
+
+	// Migrasi 0001 tetap tercatat, 0002 tidak meninggalkan tabel setengah jadi
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, statuses[0].AppliedAt)
+	assert.Nil(t, statuses[1].AppliedAt)
+	assert.False(t, db.Migrator().HasTable("b"))
+}
+
+func TestMigrator_DownWithoutDownFile(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := newMigrator(db, fstest.MapFS{
+		"0001_one_way.up.sql": {Data: []byte("CREATE TABLE a (id BIGINT);")},
+	})
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	_, err = migrator.Down(ctx, 1)
+	assert.ErrorIs(t, err, ErrNoDownMigration)
+}
+
+func TestLoad_Validation(t *testing.T) {
+	_, err := Load(fstest.MapFS{"create_orders.up.sql": {Data: []byte("SELECT 1;")}})
+	assert.ErrorContains(t, err, "nama file migrasi tidak valid")
+
+	_, err = Load(fstest.MapFS{"0001_a.down.sql": {Data: []byte("SELECT 1;")}})
+	assert.ErrorContains(t, err, "tidak punya file up")
+
+	_, err = Load(fstest.MapFS{
+		"0001_a.up.sql": {Data: []byte("SELECT 1;")},
+		"0001_b.up.sql": {Data: []byte("SELECT 1;")},
+	})
+	assert.ErrorContains(t, err, "dipakai oleh")
+}
+
+func TestMigrator_ConditionalColumnOnSQLite(t *testing.T) {
+	db := openTestDB(t)
+	migrator, err := newMigrator(db, fstest.MapFS{
+		"0001_a.up.sql":   {Data: []byte("CREATE TABLE a (id BIGINT, name TEXT);")},
+		"0002_b.up.sql":   {Data: []byte("ALTER TABLE a ADD COLUMN IF NOT EXISTS name TEXT;\nALTER TABLE a ADD COLUMN IF NOT EXISTS note TEXT;")},
+		"0002_b.down.sql": {Data: []byte("ALTER TABLE a DROP COLUMN IF EXISTS note;\nALTER TABLE a DROP COLUMN IF EXISTS missing;")},
+	})
+	require.NoError(t, err)
+
+	// Kolom yang sudah ada dilewati, bukan error "duplicate column"
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.True(t, db.Migrator().HasColumn("a", "note"))
+
+	_, err = migrator.Down(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, db.Migrator().HasColumn("a", "note"))
+	assert.True(t, db.Migrator().HasColumn("a", "name"))
+}
+
+func TestSplitStatements(t *testing.T) {
+	stmts := splitStatements("-- komentar; diabaikan\nCREATE TABLE a (id BIGINT);\n\nCREATE INDEX i ON a (id);\n")
+	assert.Equal(t, []string{"CREATE TABLE a (id BIGINT)", "CREATE INDEX i ON a (id)"}, stmts)
+}
@@ -0,0 +1,15 @@
+-- Tabel utama order. IF NOT EXISTS: database lama yang dibuat dengan
+-- AutoMigrate sudah punya tabel ini, migrasi hanya mencatat versinya.
+CREATE TABLE IF NOT EXISTS orders (
+    id           UUID PRIMARY KEY,
+    product_id   UUID NOT NULL,
+    total_price  DECIMAL(10,2) NOT NULL,
+    status       VARCHAR(50) NOT NULL,
+    created_at   TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    quantity     BIGINT NOT NULL DEFAULT 0,
+    unit_price   DECIMAL(10,2) NOT NULL DEFAULT 0,
+    product_name VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+-- Daftar order per produk diurutkan dari yang terbaru (cursor pagination)
+CREATE INDEX IF NOT EXISTS idx_orders_product_created ON orders (product_id, created_at DESC);
@@ -0,0 +1,15 @@
+-- Satu baris per produk dalam order
+CREATE TABLE IF NOT EXISTS order_items (
+    id           UUID PRIMARY KEY,
+    order_id     UUID NOT NULL,
+    product_id   UUID NOT NULL,
+    product_name VARCHAR(255) NOT NULL,
+    quantity     BIGINT NOT NULL,
+    unit_price   DECIMAL(10,2) NOT NULL,
+    subtotal     DECIMAL(10,2) NOT NULL,
+    created_at   TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_orders_items FOREIGN KEY (order_id) REFERENCES orders (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_items_order_id ON order_items (order_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_product_id ON order_items (product_id);
@@ -0,0 +1,18 @@
+-- Transactional outbox: event ditulis bersama order, di-publish oleh OutboxRelay
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              UUID PRIMARY KEY,
+    aggregate_id    UUID NOT NULL,
+    exchange        VARCHAR(255) NOT NULL,
+    routing_key     VARCHAR(255) NOT NULL,
+    payload         BYTEA NOT NULL,
+    status          VARCHAR(20) NOT NULL,
+    attempts        BIGINT NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL,
+    last_error      TEXT,
+    created_at      TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    sent_at         TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate_id ON outbox_events (aggregate_id);
+-- Relay membaca event PENDING yang sudah jatuh tempo
+CREATE INDEX IF NOT EXISTS idx_outbox_pending ON outbox_events (status, next_attempt_at);
@@ -0,0 +1,12 @@
+-- Fallback penyimpanan Idempotency-Key saat Redis tidak tersedia
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    idempotency_key VARCHAR(255) PRIMARY KEY,
+    fingerprint     VARCHAR(64) NOT NULL,
+    state           VARCHAR(20) NOT NULL,
+    status_code     BIGINT,
+    response_body   BYTEA,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,3 @@
+-- Header pesan (traceparent, tracestate, X-Request-ID) dari request asal,
+-- dipulihkan relay agar publish tersambung ke trace request tersebut
+ALTER TABLE outbox_events ADD COLUMN IF NOT EXISTS headers TEXT;
@@ -0,0 +1,2 @@
+-- Sengaja tidak menghapus kolom: di database baru kolom ini dibuat oleh 0001,
+-- dan kode order masih membutuhkannya setelah rollback ke 0005.
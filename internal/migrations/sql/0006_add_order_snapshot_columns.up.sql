@@ -0,0 +1,6 @@
+-- Snapshot produk per order. Database yang dibuat dengan AutoMigrate versi awal
+-- belum punya kolom ini (0001 hanya mencatat versinya); baris lama mendapat
+-- nilai default 0/''. Database baru sudah punya kolomnya dari 0001.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS quantity BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS unit_price DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS product_name VARCHAR(255) NOT NULL DEFAULT '';
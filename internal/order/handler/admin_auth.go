@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth adalah middleware untuk endpoint /admin/*: request wajib membawa
+// header 'Authorization: Bearer <token>' yang sama dengan token admin.
+// Dipasang setelah ErrorHandler agar respons 401 memakai format error biasa.
+func AdminAuth(token string) gin.HandlerFunc {
+	expected := []byte(token)
+	return func(c *gin.Context) {
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		// Token kosong tidak pernah cocok; perbandingan constant-time agar token tidak bisa ditebak dari waktu respons
+		if !ok || len(expected) == 0 || subtle.ConstantTimeCompare([]byte(given), expected) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.Error(&RequestError{Status: http.StatusUnauthorized, Code: CodeUnauthorized, Message: "Admin token is missing or invalid."})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAdminAuthTest(token string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ErrorHandler())
+
+	admin := router.Group("/admin", AdminAuth(token))
+	admin.GET("/dead-letters", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func TestAdminAuth(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		wantStatus    int
+	}{
+		{"token benar", "rahasia", "Bearer rahasia", http.StatusOK},
+		{"tanpa header", "rahasia", "", http.StatusUnauthorized},
+		{"token salah", "rahasia", "Bearer salah", http.StatusUnauthorized},
+		{"bukan bearer", "rahasia", "Basic rahasia", http.StatusUnauthorized},
+		{"token admin kosong", "", "Bearer ", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := setupAdminAuthTest(tt.token)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/admin/dead-letters", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusUnauthorized {
+				var resp ErrorResponse
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, CodeUnauthorized, resp.Code)
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
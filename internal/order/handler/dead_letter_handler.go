@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"challenge-order-service/internal/order/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxReplayLimit membatasi jumlah pesan yang di-replay dalam satu request
+const maxReplayLimit = 1000
+
+// maxPeekLimit membatasi jumlah pesan yang ditampilkan dalam satu request
+const maxPeekLimit = 100
+
+// DeadLetterHandler menangani endpoint admin untuk dead-letter queue
+type DeadLetterHandler struct {
+	Service service.DeadLetterService
+}
+
+// NewDeadLetterHandler adalah constructor untuk DeadLetterHandler
+func NewDeadLetterHandler(svc service.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{Service: svc}
+}
+
+// ListDeadLetters menangani endpoint GET /admin/dead-letters
+func (h *DeadLetterHandler) ListDeadLetters(c *gin.Context) {
+	stats, err := h.Service.Stats(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"queues": stats})
+}
+
+// PeekDeadLetters menangani endpoint GET /admin/dead-letters/:queue?limit=N:
+// isi pesan, routing key dan header (x-last-error, x-retry-count) tanpa mengubah DLQ
+func (h *DeadLetterHandler) PeekDeadLetters(c *gin.Context) {
+	// 1. Validasi limit
+	limit := service.DefaultPeekLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxPeekLimit {
+			c.Error(badRequest("limit must be between 1 and 100.", err))
+			return
+		}
+		limit = parsed
+	}
+
+	// 2. Baca pesan terdepan; pesan dikembalikan ke DLQ oleh service
+	queue := c.Param("queue")
+	messages, err := h.Service.Peek(c.Request.Context(), queue, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"queue": queue, "messages": messages})
+}
+
+// ReplayDeadLetters menangani endpoint POST /admin/dead-letters/:queue/replay?limit=N
+func (h *DeadLetterHandler) ReplayDeadLetters(c *gin.Context) {
+	// 1. Validasi limit
+	limit := service.DefaultReplayLimit
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > maxReplayLimit {
+			c.Error(badRequest("limit must be between 1 and 1000.", err))
+			return
+		}
+		limit = parsed
+	}
+
+	// 2. Replay; pesan yang sudah dipindahkan tetap dilaporkan walau terjadi error
+	queue := c.Param("queue")
+	replayed, err := h.Service.Replay(c.Request.Context(), queue, limit)
+	if err != nil {
+		if replayed > 0 {
+			c.Header("X-Replayed-Count", strconv.Itoa(replayed))
+		}
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"queue": queue, "replayed": replayed})
+}
@@ -0,0 +1,162 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"challenge-order-service/internal/order/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockDeadLetterService adalah mock untuk service.DeadLetterService
+type MockDeadLetterService struct {
+	mock.Mock
+}
+
+func (m *MockDeadLetterService) Stats(ctx context.Context) ([]service.DeadLetterQueueStats, error) {
+	args := m.Called(ctx)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.DeadLetterQueueStats), args.Error(1)
+}
+
+func (m *MockDeadLetterService) Peek(ctx context.Context, queue string, limit int) ([]service.DeadLetterMessage, error) {
+	args := m.Called(ctx, queue, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]service.DeadLetterMessage), args.Error(1)
+}
+
+func (m *MockDeadLetterService) Replay(ctx context.Context, queue string, limit int) (int, error) {
+	args := m.Called(ctx, queue, limit)
+	return args.Int(0), args.Error(1)
+}
+
+func setupDeadLetterTest(mockSvc *MockDeadLetterService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.Use(ErrorHandler())
+
+	handler := NewDeadLetterHandler(mockSvc)
+	router.GET("/admin/dead-letters", handler.ListDeadLetters)
+	router.GET("/admin/dead-letters/:queue", handler.PeekDeadLetters)
+	router.POST("/admin/dead-letters/:queue/replay", handler.ReplayDeadLetters)
+	return router
+}
+
+func TestListDeadLetters(t *testing.T) {
+	mockSvc := new(MockDeadLetterService)
+	router := setupDeadLetterTest(mockSvc)
+
+	stats := []service.DeadLetterQueueStats{{Queue: "q.orders.log", DeadLetterQueue: "q.orders.log.dlq", Messages: 3}}
+	mockSvc.On("Stats", mock.Anything).Return(stats, nil).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/dead-letters", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Queues []service.DeadLetterQueueStats `json:"queues"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, stats, resp.Queues)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestReplayDeadLetters_Success(t *testing.T) {
+	mockSvc := new(MockDeadLetterService)
+	router := setupDeadLetterTest(mockSvc)
+
+	mockSvc.On("Replay", mock.Anything, "q.orders.log", 10).Return(7, nil).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/dead-letters/q.orders.log/replay?limit=10", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"queue":"q.orders.log","replayed":7}`, w.Body.String())
+	mockSvc.AssertExpectations(t)
+}
+
+func TestReplayDeadLetters_UnknownQueue(t *testing.T) {
+	mockSvc := new(MockDeadLetterService)
+	router := setupDeadLetterTest(mockSvc)
+
+	mockSvc.On("Replay", mock.Anything, "q.unknown", service.DefaultReplayLimit).
+		Return(0, service.ErrUnknownDeadLetterQueue).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/dead-letters/q.unknown/replay", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestReplayDeadLetters_InvalidLimit(t *testing.T) {
+	mockSvc := new(MockDeadLetterService)
+	router := setupDeadLetterTest(mockSvc)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/admin/dead-letters/q.orders.log/replay?limit=0", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSvc.AssertNotCalled(t, "Replay", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPeekDeadLetters_Success(t *testing.T) {
+	mockSvc := new(MockDeadLetterService)
+	router := setupDeadLetterTest(mockSvc)
+
+	messages := []service.DeadLetterMessage{{
+		Exchange: "orders_exchange", RoutingKey: "order.created",
+		RetryCount: 5, LastError: "db down", Body: `{"orderId":"1"}`,
+	}}
+	mockSvc.On("Peek", mock.Anything, "q.orders.log", 5).Return(messages, nil).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/dead-letters/q.orders.log?limit=5", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp struct {
+		Queue    string                      `json:"queue"`
+		Messages []service.DeadLetterMessage `json:"messages"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "q.orders.log", resp.Queue)
+	if assert.Len(t, resp.Messages, 1) {
+		assert.Equal(t, "db down", resp.Messages[0].LastError)
+		assert.Equal(t, 5, resp.Messages[0].RetryCount)
+		assert.Equal(t, `{"orderId":"1"}`, resp.Messages[0].Body)
+	}
+	mockSvc.AssertExpectations(t)
+}
+
+func TestPeekDeadLetters_InvalidLimitAndUnknownQueue(t *testing.T) {
+	mockSvc := new(MockDeadLetterService)
+	router := setupDeadLetterTest(mockSvc)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/admin/dead-letters/q.orders.log?limit=101", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockSvc.On("Peek", mock.Anything, "q.unknown", service.DefaultPeekLimit).
+		Return(nil, service.ErrUnknownDeadLetterQueue).Once()
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/admin/dead-letters/q.unknown", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockSvc.AssertExpectations(t)
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/order"
+	"challenge-order-service/internal/order/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Kode error yang stabil untuk klien (field "code" di body error).
+// Pesan di field "error" boleh berubah; kode tidak.
+const (
+	CodeInvalidRequest          = "INVALID_REQUEST"
+	CodeValidationFailed        = "VALIDATION_FAILED"
+	CodeInvalidStatus           = "INVALID_STATUS"
+	CodeInsufficientStock       = "INSUFFICIENT_STOCK"
+	CodeInvalidStatusTransition = "INVALID_STATUS_TRANSITION"
+	CodeProductNotFound         = "PRODUCT_NOT_FOUND"
+	CodeOrderNotFound           = "ORDER_NOT_FOUND"
+	CodeNotFound                = "NOT_FOUND"
+	CodeUnauthorized            = "UNAUTHORIZED"
+	CodeIdempotencyKeyReused    = "IDEMPOTENCY_KEY_REUSED"
+	CodeIdempotencyInProgress   = "IDEMPOTENCY_IN_PROGRESS"
+	CodeUpstreamUnavailable     = "UPSTREAM_UNAVAILABLE"
+	CodeOverloaded              = "OVERLOADED"
+	CodeInternal                = "INTERNAL_ERROR"
+)
+
+// ErrorResponse adalah bentuk body untuk semua respons error
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Error   string `json:"error"`
+	Details string `json:"details,omitempty"`
+}
+
+// RequestError adalah error yang dibuat handler sendiri (mis. format input salah)
+// dengan status dan kode yang sudah ditentukan
+type RequestError struct {
+	Status  int
+	Code    string
+	Message string
+	Details string
+}
+
+func (e *RequestError) Error() string { return e.Message }
+
+// badRequest membuat RequestError 400 untuk input yang tidak bisa di-parse
+func badRequest(message string, cause error) *RequestError {
+	err := &RequestError{Status: http.StatusBadRequest, Code: CodeInvalidRequest, Message: message}
+	if cause != nil {
+		err.Details = cause.Error()
+	}
+	return err
+}
+
+// errorMapping memetakan satu error domain ke status HTTP dan kode
+type errorMapping struct {
+	target     error
+	status     int
+	code       string
+	retryAfter string // detik, kosong = tanpa header Retry-After
+}
+
+// errorMappings diperiksa berurutan dengan errors.Is; yang pertama cocok dipakai
+var errorMappings = []errorMapping{
+	{service.ErrValidation, http.StatusUnprocessableEntity, CodeValidationFailed, ""},
+	{order.ErrInvalidOrderRequest, http.StatusUnprocessableEntity, CodeValidationFailed, ""},
+	// Query string yang salah format atau di luar batas sama-sama 400
+	{order.ErrInvalidOrderQuery, http.StatusBadRequest, CodeInvalidRequest, ""},
+	{order.ErrInvalidStatus, http.StatusBadRequest, CodeInvalidStatus, ""},
+	{service.ErrInsufficientStock, http.StatusConflict, CodeInsufficientStock, ""},
+	{order.ErrInvalidStatusTransition, http.StatusConflict, CodeInvalidStatusTransition, ""},
+	{service.ErrProductNotFound, http.StatusNotFound, CodeProductNotFound, ""},
+	{order.ErrOrderNotFound, http.StatusNotFound, CodeOrderNotFound, ""},
+	{service.ErrUnknownDeadLetterQueue, http.StatusNotFound, CodeNotFound, ""},
+	// Antrean mode asinkron penuh: minta klien mencoba lagi (backpressure)
+	{service.ErrQueueFull, http.StatusServiceUnavailable, CodeOverloaded, "1"},
+	{service.ErrWriterClosed, http.StatusServiceUnavailable, CodeOverloaded, "1"},
+	// product-service tidak bisa dihubungi / circuit breaker terbuka
+	{service.ErrUpstreamUnavailable, http.StatusServiceUnavailable, CodeUpstreamUnavailable, "5"},
+	{service.ErrBrokerNotConnected, http.StatusServiceUnavailable, CodeUpstreamUnavailable, "5"},
+}
+
+// errorResponse menentukan status dan body untuk err, termasuk header Retry-After.
+// Error yang tidak dikenal menjadi 500 tanpa membocorkan pesan aslinya.
+func errorResponse(c *gin.Context, err error) (int, ErrorResponse) {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.Status, ErrorResponse{Code: reqErr.Code, Error: reqErr.Message, Details: reqErr.Details}
+	}
+
+	for _, m := range errorMappings {
+		if errors.Is(err, m.target) {
+			if m.retryAfter != "" {
+				c.Header("Retry-After", m.retryAfter)
+			}
+			return m.status, ErrorResponse{Code: m.code, Error: err.Error()}
+		}
+	}
+
+	slog.ErrorContext(c.Request.Context(), "request gagal dengan error internal",
+		slog.String("method", c.Request.Method), slog.String("path", c.Request.URL.Path), logging.Err(err))
+	return http.StatusInternalServerError, ErrorResponse{Code: CodeInternal, Error: "Internal server error."}
+}
+
+// ErrorHandler adalah middleware yang menulis respons untuk error terakhir yang
+// didaftarkan handler lewat c.Error, jika handler belum menulis respons sendiri.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		status, body := errorResponse(c, c.Errors.Last().Err)
+		c.JSON(status, body)
+	}
+}
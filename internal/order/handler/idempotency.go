@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/order"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// IdempotencyKeyHeader adalah header yang dipakai klien untuk retry yang aman
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader ditandai "true" pada respons hasil replay
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	idempotencyPollInterval = 50 * time.Millisecond
+)
+
+// createOrderIdempotent menjalankan CreateOrder paling banyak satu kali per Idempotency-Key.
+// Request duplikat menerima ulang respons pertama tanpa memanggil OrderService.
+func (h *OrderHandler) createOrderIdempotent(c *gin.Context, key string, req order.CreateOrderRequest) {
+	ctx := c.Request.Context()
+	fingerprint := requestFingerprint(c)
+
+	// 1. Kunci key untuk request ini
+	existing, acquired, err := h.idempotency.Acquire(ctx, key, fingerprint)
+	if err != nil {
+		// Penyimpanan tidak tersedia sama sekali: layani request tanpa jaminan idempotensi
+		h.logger.WarnContext(ctx, "Idempotency-Key tidak bisa dikunci, request diproses tanpa idempotensi",
+			slog.String("idempotency_key", key), logging.Err(err))
+		status, payload := h.createOrder(c, req)
+		c.JSON(status, payload)
+		return
+	}
+
+	// 2. Key sudah dipakai: tunggu / replay respons pertama
+	if !acquired {
+		h.replayIdempotent(c, key, fingerprint, existing)
+		return
+	}
+
+	// 3. Request pertama: proses lalu simpan responsnya
+	status, payload := h.createOrder(c, req)
+	body, _ := json.Marshal(payload)
+
+	// Respons tetap disimpan walaupun klien sudah memutus koneksi,
+	// karena order-nya mungkin sudah terbuat
+	storeCtx := context.WithoutCancel(ctx)
+	if status >= http.StatusInternalServerError {
+		// Error sementara tidak disimpan agar klien bisa retry dengan key yang sama
+		if err := h.idempotency.Release(storeCtx, key); err != nil {
+			h.logger.WarnContext(ctx, "gagal melepas Idempotency-Key", slog.String("idempotency_key", key), logging.Err(err))
+		}
+	} else if err := h.idempotency.Complete(storeCtx, key, fingerprint, status, body); err != nil {
+		h.logger.WarnContext(ctx, "gagal menyimpan respons untuk Idempotency-Key",
+			slog.String("idempotency_key", key), logging.Err(err))
+	}
+
+	c.Data(status, gin.MIMEJSON+"; charset=utf-8", body)
+}
+
+// replayIdempotent mengembalikan respons yang tersimpan untuk key, menunggu
+// sebentar jika request pertama masih diproses.
+func (h *OrderHandler) replayIdempotent(c *gin.Context, key, fingerprint string, existing *order.IdempotencyRecord) {
+	if existing != nil && existing.Fingerprint != fingerprint {
+		c.Error(&RequestError{
+			Status:  http.StatusUnprocessableEntity,
+			Code:    CodeIdempotencyKeyReused,
+			Message: "Idempotency-Key has already been used with a different request payload.",
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+	deadline := time.Now().Add(h.idempotencyWait)
+	for existing != nil && existing.State == order.IdempotencyInProgress && time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			// Klien sudah pergi, tidak perlu menunggu lagi
+			return
+		case <-time.After(idempotencyPollInterval):
+		}
+
+		var err error
+		if existing, err = h.idempotency.Get(ctx, key); err != nil {
+			h.logger.WarnContext(ctx, "gagal membaca Idempotency-Key", slog.String("idempotency_key", key), logging.Err(err))
+			break
+		}
+	}
+
+	if existing == nil || existing.State != order.IdempotencyCompleted {
+		c.Error(&RequestError{
+			Status:  http.StatusConflict,
+			Code:    CodeIdempotencyInProgress,
+			Message: "A request with the same Idempotency-Key is still being processed. Retry later.",
+		})
+		return
+	}
+
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Data(existing.StatusCode, gin.MIMEJSON+"; charset=utf-8", existing.ResponseBody)
+}
+
+// requestFingerprint adalah SHA-256 dari body request yang sudah di-bind
+func requestFingerprint(c *gin.Context) string {
+	var raw []byte
+	if body, ok := c.Get(gin.BodyBytesKey); ok {
+		raw, _ = body.([]byte)
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
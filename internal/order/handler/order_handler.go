@@ -1,13 +1,21 @@
 package handler
 
 import (
+	"challenge-order-service/internal/metrics"
 	"challenge-order-service/internal/order"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	// PERBAIKAN: Import package service karena interface OrderService didefinisikan di sana.
 	"challenge-order-service/internal/order/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/google/uuid"
 )
 
@@ -16,14 +24,41 @@ import (
 type OrderHandler struct {
 	// Menggunakan service.OrderService (SOLUSI UNTUK COMPILATION ERROR)
 	Service service.OrderService
+
+	idempotency     service.IdempotencyStore // nil = header Idempotency-Key diabaikan
+	idempotencyWait time.Duration            // lama menunggu request duplikat yang masih diproses
+	logger          *slog.Logger
+}
+
+// HandlerOption mengatur fitur opsional OrderHandler
+type HandlerOption func(*OrderHandler)
+
+// WithIdempotencyStore mengaktifkan dukungan header Idempotency-Key di POST /orders
+func WithIdempotencyStore(store service.IdempotencyStore) HandlerOption {
+	return func(h *OrderHandler) {
+		h.idempotency = store
+	}
+}
+
+// WithLogger mengganti logger handler (default slog.Default())
+func WithLogger(logger *slog.Logger) HandlerOption {
+	return func(h *OrderHandler) {
+		h.logger = logger
+	}
 }
 
 // NewOrderHandler adalah constructor untuk handler.
 // Menggunakan service.OrderService (SOLUSI UNTUK COMPILATION ERROR)
-func NewOrderHandler(svc service.OrderService) *OrderHandler {
-	return &OrderHandler{
-		Service: svc,
+func NewOrderHandler(svc service.OrderService, opts ...HandlerOption) *OrderHandler {
+	h := &OrderHandler{
+		Service:         svc,
+		idempotencyWait: 2 * time.Second,
+		logger:          slog.Default(),
+	}
+	for _, opt := range opts {
+		opt(h)
 	}
+	return h
 }
 
 // CreateOrder menangani endpoint POST /orders
@@ -31,29 +66,83 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	var req order.CreateOrderRequest
 
 	// 1. Binding dan Validasi Input
-	if err := c.ShouldBindJSON(&req); err != nil {
+	// ShouldBindBodyWith menyimpan body mentah untuk fingerprint Idempotency-Key
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
 		// Mengembalikan 400 Bad Request
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format or missing field.", "details": err.Error()})
+		c.Error(badRequest("Invalid request format or missing field.", err))
 		return
 	}
 
-	// 2. Panggil Service Layer
-	createdOrder, err := h.Service.CreateOrder(req)
+	// 2. Request dengan Idempotency-Key diproses lewat IdempotencyStore
+	key := c.GetHeader(IdempotencyKeyHeader)
+	if key != "" && h.idempotency != nil {
+		if len(key) > maxIdempotencyKeyLength {
+			c.Error(badRequest("Idempotency-Key is too long.", nil))
+			return
+		}
+		h.createOrderIdempotent(c, key, req)
+		return
+	}
+
+	status, payload := h.createOrder(c, req)
+	c.JSON(status, payload)
+}
+
+// createOrder memanggil Service Layer dan menentukan status HTTP beserta payload respons
+func (h *OrderHandler) createOrder(c *gin.Context, req order.CreateOrderRequest) (int, interface{}) {
+	// 1. Panggil Service Layer
+	// Context request diteruskan agar pembatalan dari klien menghentikan pekerjaan di bawahnya
+	createdOrder, err := h.Service.CreateOrder(c.Request.Context(), req)
 
 	if err != nil {
-		// 3. Penanganan Error dari Service
-		// Mengembalikan 500 Internal Server Error untuk error dari layer di bawahnya
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		// 2. Penanganan Error dari Service
+		// Dipetakan langsung (bukan lewat middleware) karena respons ini
+		// juga disimpan untuk Idempotency-Key
+		status, body := errorResponse(c, err)
+		metrics.OrdersCreated.WithLabelValues(strings.ToLower(body.Code)).Inc()
+		return status, body
 	}
 
-	// 4. Sukses Response
+	// 3. Sukses Response
+	// Mode asinkron: order sudah diterima tapi belum tersimpan, jadi 202 Accepted
+	if h.Service.AsyncMode() {
+		metrics.OrdersCreated.WithLabelValues("accepted").Inc()
+		return http.StatusAccepted, createdOrder
+	}
+	metrics.OrdersCreated.WithLabelValues("created").Inc()
+
 	// PENTING: Mengembalikan objek 'createdOrder' (SOLUSI UNTUK TEST FAILURE)
 	// Gin akan men-marshal struct ini menjadi JSON: {"id": "...", "product_id": "...", ...}
-	c.JSON(http.StatusCreated, createdOrder)
+	return http.StatusCreated, createdOrder
+}
+
+// GetOrderByID menangani endpoint GET /orders/:id
+func (h *OrderHandler) GetOrderByID(c *gin.Context) {
+	// 1. Validasi Parameter UUID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(badRequest("Invalid Order ID format.", err))
+		return
+	}
+
+	// 2. Panggil Service Layer
+	found, err := h.Service.GetOrderByID(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// 3. Sukses Response
+	c.JSON(http.StatusOK, found)
 }
 
+// NextCursorHeader berisi cursor halaman berikutnya (kosong = halaman terakhir)
+const NextCursorHeader = "X-Next-Cursor"
+
 // GetOrdersByProductID menangani endpoint GET /orders/product/:productID
+// Query opsional: limit, status, from, to (RFC3339) dan cursor.
+// Body tetap berupa array order (kompatibel dengan klien lama); cursor halaman
+// berikutnya dikirim lewat header X-Next-Cursor.
 func (h *OrderHandler) GetOrdersByProductID(c *gin.Context) {
 	productIDParam := c.Param("productID")
 
@@ -61,19 +150,99 @@ func (h *OrderHandler) GetOrdersByProductID(c *gin.Context) {
 	productID, err := uuid.Parse(productIDParam)
 	if err != nil {
 		// Mengembalikan 400 Bad Request
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Product ID format."})
+		c.Error(badRequest("Invalid Product ID format.", err))
 		return
 	}
 
-	// 2. Panggil Service Layer
-	orders, err := h.Service.GetOrdersByProductID(productID)
+	// 2. Validasi Query Filter & Pagination
+	query, err := parseOrderListQuery(c)
+	if err != nil {
+		c.Error(badRequest(err.Error(), nil))
+		return
+	}
+
+	// 3. Panggil Service Layer
+	page, err := h.Service.GetOrdersByProductID(c.Request.Context(), productID, query)
 
 	if err != nil {
-		// Mengembalikan 500 Internal Server Error untuk error dari layer di bawahnya
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		// Dipetakan ke status HTTP oleh middleware ErrorHandler
+		c.Error(err)
 		return
 	}
 
-	// 3. Sukses Response
+	// 4. Sukses Response
+	if page.NextCursor != "" {
+		c.Header(NextCursorHeader, page.NextCursor)
+	}
+	orders := page.Orders
+	if orders == nil {
+		orders = []order.Order{}
+	}
 	c.JSON(http.StatusOK, orders)
 }
+
+// parseOrderListQuery membaca query string menjadi order.OrderListQuery
+func parseOrderListQuery(c *gin.Context) (order.OrderListQuery, error) {
+	var query order.OrderListQuery
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return query, fmt.Errorf("Invalid limit: %q", raw)
+		}
+		query.Limit = limit
+	}
+	if raw := c.Query("status"); raw != "" {
+		query.Status = order.OrderStatus(strings.ToUpper(raw))
+	}
+	if raw := c.Query("from"); raw != "" {
+		from, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return query, fmt.Errorf("Invalid 'from' timestamp, expected RFC3339: %q", raw)
+		}
+		query.CreatedFrom = &from
+	}
+	if raw := c.Query("to"); raw != "" {
+		to, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return query, fmt.Errorf("Invalid 'to' timestamp, expected RFC3339: %q", raw)
+		}
+		query.CreatedTo = &to
+	}
+	if raw := c.Query("cursor"); raw != "" {
+		cursor, err := order.DecodeOrderCursor(raw)
+		if err != nil {
+			return query, errors.New("Invalid cursor.")
+		}
+		query.Cursor = cursor
+	}
+	return query, nil
+}
+
+// UpdateOrderStatus menangani endpoint PATCH /orders/:id/status
+func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
+	// 1. Validasi Parameter UUID
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.Error(badRequest("Invalid Order ID format.", err))
+		return
+	}
+
+	// 2. Binding dan Validasi Input
+	var req order.UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(badRequest("Invalid request format or missing field.", err))
+		return
+	}
+
+	// 3. Panggil Service Layer
+	updatedOrder, err := h.Service.UpdateOrderStatus(c.Request.Context(), id, req.Status)
+	if err != nil {
+		// 4. Error domain dipetakan ke status HTTP oleh middleware ErrorHandler
+		c.Error(err)
+		return
+	}
+
+	// 5. Sukses Response
+	c.JSON(http.StatusOK, updatedOrder)
+}
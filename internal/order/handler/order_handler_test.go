@@ -2,16 +2,26 @@ package handler
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"challenge-order-service/internal/metrics"
 	"challenge-order-service/internal/order"
+	"challenge-order-service/internal/order/repository"
+	"challenge-order-service/internal/order/service"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -24,8 +34,17 @@ type MockOrderService struct {
 }
 
 // CreateOrder: Mock sesuai interface service
-func (m *MockOrderService) CreateOrder(req order.CreateOrderRequest) (*order.Order, error) {
-	args := m.Called(req)
+func (m *MockOrderService) CreateOrder(ctx context.Context, req order.CreateOrderRequest) (*order.Order, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*order.Order), args.Error(1)
+}
+
+// GetOrderByID: Mock sesuai interface service
+func (m *MockOrderService) GetOrderByID(ctx context.Context, id uuid.UUID) (*order.Order, error) {
+	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -33,12 +52,36 @@ func (m *MockOrderService) CreateOrder(req order.CreateOrderRequest) (*order.Ord
 }
 
 // GetOrdersByProductID: Mock sesuai interface service
-func (m *MockOrderService) GetOrdersByProductID(productID uuid.UUID) ([]order.Order, error) {
-	args := m.Called(productID)
+func (m *MockOrderService) GetOrdersByProductID(ctx context.Context, productID uuid.UUID, query order.OrderListQuery) (*order.OrderPage, error) {
+	args := m.Called(ctx, productID, query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*order.OrderPage), args.Error(1)
+}
+
+// UpdateOrderStatus: Mock sesuai interface service
+func (m *MockOrderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, status order.OrderStatus) (*order.Order, error) {
+	args := m.Called(ctx, id, status)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).([]order.Order), args.Error(1)
+	return args.Get(0).(*order.Order), args.Error(1)
+}
+
+// FinalizeOrder: Mock sesuai interface service
+func (m *MockOrderService) FinalizeOrder(ctx context.Context, id uuid.UUID, status order.OrderStatus, reason string) (*order.Order, error) {
+	args := m.Called(ctx, id, status, reason)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*order.Order), args.Error(1)
+}
+
+// AsyncMode: Mock sesuai interface service
+func (m *MockOrderService) AsyncMode() bool {
+	args := m.Called()
+	return args.Bool(0)
 }
 
 // --- SETUP TEST ---
@@ -48,17 +91,59 @@ func setupTest(mockSvc *MockOrderService) (*gin.Engine, *OrderHandler) {
 	// Ganti mode ke test untuk menonaktifkan log Gin
 	gin.SetMode(gin.TestMode)
 	router := gin.Default()
+	router.Use(ErrorHandler())
+
+	// Default: mode sinkron (test yang butuh mode asinkron mendaftarkan ekspektasinya lebih dulu)
+	mockSvc.On("AsyncMode").Return(false).Maybe()
 
 	// Gunakan NewOrderHandler (pastikan signature di handler.go sesuai)
 	handler := NewOrderHandler(mockSvc)
 
 	// Definisikan endpoint sesuai main.go
 	router.POST("/orders", handler.CreateOrder)
+	router.GET("/orders/:id", handler.GetOrderByID)
 	router.GET("/orders/product/:productID", handler.GetOrdersByProductID)
+	router.PATCH("/orders/:id/status", handler.UpdateOrderStatus)
 
 	return router, handler
 }
 
+// setupIdempotentTest sama dengan setupTest, ditambah IdempotencyStore berbasis miniredis
+func setupIdempotentTest(t *testing.T, mockSvc *MockOrderService) *gin.Engine {
+	router, _ := setupIdempotentTestWithRepo(t, mockSvc, nil)
+	return router
+}
+
+// setupIdempotentTestWithRepo memakai repo sebagai fallback DB IdempotencyStore
+func setupIdempotentTestWithRepo(t *testing.T, mockSvc *MockOrderService, repo repository.IdempotencyRepository) (*gin.Engine, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	t.Cleanup(mr.Close)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	router.Use(ErrorHandler())
+	mockSvc.On("AsyncMode").Return(false).Maybe()
+
+	handler := NewOrderHandler(mockSvc, WithIdempotencyStore(service.NewIdempotencyStore(rdb, repo)))
+	handler.idempotencyWait = 200 * time.Millisecond
+	router.POST("/orders", handler.CreateOrder)
+	return router, mr
+}
+
+func postOrder(router *gin.Engine, body interface{}, idempotencyKey string) *httptest.ResponseRecorder {
+	reqBodyJSON, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/orders", bytes.NewBuffer(reqBodyJSON))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+	router.ServeHTTP(w, req)
+	return w
+}
+
 // --- TEST CASES: POST /orders ---
 
 func TestCreateOrder_Success(t *testing.T) {
@@ -78,7 +163,7 @@ func TestCreateOrder_Success(t *testing.T) {
 	}
 
 	// 1. Arrange: Mock Service (memastikan Handler memanggil Service dengan benar)
-	mockSvc.On("CreateOrder", reqBody).Return(expectedOrder, nil).Once()
+	mockSvc.On("CreateOrder", mock.Anything, reqBody).Return(expectedOrder, nil).Once()
 
 	// 2. Act
 	reqBodyJSON, _ := json.Marshal(reqBody)
@@ -98,6 +183,239 @@ func TestCreateOrder_Success(t *testing.T) {
 	mockSvc.AssertExpectations(t)
 }
 
+func TestCreateOrder_MultipleItems(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router, _ := setupTest(mockSvc)
+
+	reqBody := order.CreateOrderRequest{Items: []order.CreateOrderItemRequest{
+		{ProductID: uuid.New(), Quantity: 1},
+		{ProductID: uuid.New(), Quantity: 2},
+	}}
+	mockSvc.On("CreateOrder", mock.Anything, reqBody).Return(&order.Order{ID: uuid.New()}, nil).Once()
+
+	reqBodyJSON, _ := json.Marshal(reqBody)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/orders", bytes.NewBuffer(reqBodyJSON))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateOrder_InvalidItems(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router, _ := setupTest(mockSvc)
+
+	// Item tanpa quantity ditolak oleh binding
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/orders", bytes.NewBufferString(`{"items":[{"productId":"`+uuid.New().String()+`"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockSvc.AssertNotCalled(t, "CreateOrder", mock.Anything)
+}
+
+func TestCreateOrder_AsyncModeAccepted(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	mockSvc.On("AsyncMode").Return(true)
+	router, _ := setupTest(mockSvc)
+
+	reqBody := order.CreateOrderRequest{ProductID: uuid.New(), Quantity: 1}
+	queuedOrder := &order.Order{ID: uuid.New(), ProductID: reqBody.ProductID, Status: order.StatusPending}
+	mockSvc.On("CreateOrder", mock.Anything, reqBody).Return(queuedOrder, nil).Once()
+
+	reqBodyJSON, _ := json.Marshal(reqBody)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/orders", bytes.NewBuffer(reqBodyJSON))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateOrder_QueueFull(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router, _ := setupTest(mockSvc)
+
+	reqBody := order.CreateOrderRequest{ProductID: uuid.New(), Quantity: 1}
+	mockSvc.On("CreateOrder", mock.Anything, reqBody).Return(nil, service.ErrQueueFull).Once()
+
+	reqBodyJSON, _ := json.Marshal(reqBody)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/orders", bytes.NewBuffer(reqBodyJSON))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "1", w.Header().Get("Retry-After"))
+}
+
+func TestCreateOrder_ProductServiceUnavailable(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router, _ := setupTest(mockSvc)
+
+	reqBody := order.CreateOrderRequest{ProductID: uuid.New(), Quantity: 1}
+	svcErr := fmt.Errorf("%w: circuit breaker terbuka", service.ErrProductServiceUnavailable)
+	mockSvc.On("CreateOrder", mock.Anything, reqBody).Return(nil, svcErr).Once()
+
+	reqBodyJSON, _ := json.Marshal(reqBody)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/orders", bytes.NewBuffer(reqBodyJSON))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateOrder_DomainErrorMapping(t *testing.T) {
+	cases := []struct {
+		name         string
+		svcErr       error
+		expected     int
+		expectedCode string
+	}{
+		{"insufficient stock", fmt.Errorf("%w: produk x", service.ErrInsufficientStock), http.StatusConflict, CodeInsufficientStock},
+		{"product not found", service.ErrProductNotFound, http.StatusNotFound, CodeProductNotFound},
+		{"validation", fmt.Errorf("%w: %w", service.ErrValidation, order.ErrInvalidOrderRequest), http.StatusUnprocessableEntity, CodeValidationFailed},
+		{"upstream unavailable", service.ErrProductServiceUnavailable, http.StatusServiceUnavailable, CodeUpstreamUnavailable},
+		{"unexpected error", errors.New("pq: connection refused"), http.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockOrderService)
+			router, _ := setupTest(mockSvc)
+
+			reqBody := order.CreateOrderRequest{ProductID: uuid.New(), Quantity: 1}
+			mockSvc.On("CreateOrder", mock.Anything, reqBody).Return(nil, tc.svcErr).Once()
+			outcome := metrics.OrdersCreated.WithLabelValues(strings.ToLower(tc.expectedCode))
+			before := testutil.ToFloat64(outcome)
+
+			w := postOrder(router, reqBody, "")
+
+			assert.Equal(t, tc.expected, w.Code)
+			assert.Equal(t, before+1, testutil.ToFloat64(outcome))
+			var resp ErrorResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tc.expectedCode, resp.Code)
+			assert.NotEmpty(t, resp.Error)
+			// Pesan error internal tidak dikirim ke klien
+			assert.NotContains(t, w.Body.String(), "pq:")
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCreateOrder_IdempotencyKeyReplay(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router := setupIdempotentTest(t, mockSvc)
+
+	reqBody := order.CreateOrderRequest{ProductID: uuid.New(), Quantity: 2}
+	createdOrder := &order.Order{ID: uuid.New(), ProductID: reqBody.ProductID, TotalPrice: 200}
+
+	// Service hanya boleh dipanggil satu kali
+	mockSvc.On("CreateOrder", mock.Anything, reqBody).Return(createdOrder, nil).Once()
+
+	first := postOrder(router, reqBody, "retry-123")
+	second := postOrder(router, reqBody, "retry-123")
+
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.JSONEq(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateOrder_IdempotencyKeyReplayAfterRedisOutage(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	mockRepo := new(repository.MockIdempotencyRepository)
+	router, mr := setupIdempotentTestWithRepo(t, mockSvc, mockRepo)
+
+	reqBody := order.CreateOrderRequest{ProductID: uuid.New(), Quantity: 2}
+	mockSvc.On("CreateOrder", mock.Anything, reqBody).Return(&order.Order{ID: uuid.New(), ProductID: reqBody.ProductID}, nil).Once()
+
+	// 1. Redis mati: key dikunci dan diselesaikan di DB saja
+	var completed *order.IdempotencyRecord
+	mockRepo.On("Insert", mock.Anything, mock.Anything).Return(true, nil).Once()
+	mockRepo.On("Upsert", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		completed = args.Get(1).(*order.IdempotencyRecord)
+	}).Return(nil).Once()
+
+	mr.Close()
+	first := postOrder(router, reqBody, "outage-key")
+	assert.Equal(t, http.StatusCreated, first.Code)
+
+	// 2. Redis pulih tanpa salinan respons; retry tetap memakai record di DB
+	assert.NoError(t, mr.Restart())
+	assert.False(t, mr.Exists("idempotency:outage-key"))
+	mockRepo.On("Find", mock.Anything, "outage-key").Return(completed, nil).Once()
+
+	second := postOrder(router, reqBody, "outage-key")
+
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.JSONEq(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+	assert.False(t, mr.Exists("idempotency:outage-key"), "kunci Redis dilepas setelah record DB ditemukan")
+	mockSvc.AssertExpectations(t)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateOrder_IdempotencyKeyDifferentPayload(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router := setupIdempotentTest(t, mockSvc)
+
+	firstReq := order.CreateOrderRequest{ProductID: uuid.New(), Quantity: 1}
+	mockSvc.On("CreateOrder", mock.Anything, firstReq).Return(&order.Order{ID: uuid.New()}, nil).Once()
+
+	postOrder(router, firstReq, "reused-key")
+	w := postOrder(router, order.CreateOrderRequest{ProductID: uuid.New(), Quantity: 3}, "reused-key")
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateOrder_IdempotencyKeyServerErrorIsRetryable(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router := setupIdempotentTest(t, mockSvc)
+
+	reqBody := order.CreateOrderRequest{ProductID: uuid.New(), Quantity: 1}
+	mockSvc.On("CreateOrder", mock.Anything, reqBody).Return(nil, errors.New("db down")).Once()
+	mockSvc.On("CreateOrder", mock.Anything, reqBody).Return(&order.Order{ID: uuid.New()}, nil).Once()
+
+	// Respons 5xx tidak disimpan, jadi retry dengan key yang sama diproses ulang
+	assert.Equal(t, http.StatusInternalServerError, postOrder(router, reqBody, "flaky").Code)
+	assert.Equal(t, http.StatusCreated, postOrder(router, reqBody, "flaky").Code)
+	mockSvc.AssertExpectations(t)
+}
+
+func TestCreateOrder_IdempotencyKeyConcurrentDuplicate(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router := setupIdempotentTest(t, mockSvc)
+
+	reqBody := order.CreateOrderRequest{ProductID: uuid.New(), Quantity: 1}
+	release := make(chan struct{})
+	mockSvc.On("CreateOrder", mock.Anything, reqBody).
+		Run(func(mock.Arguments) { <-release }).
+		Return(&order.Order{ID: uuid.New()}, nil).Once()
+
+	// Request pertama tertahan di service selama request kedua menunggu
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postOrder(router, reqBody, "in-flight") }()
+	time.Sleep(50 * time.Millisecond)
+
+	duplicate := postOrder(router, reqBody, "in-flight")
+	assert.Equal(t, http.StatusConflict, duplicate.Code)
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+	mockSvc.AssertExpectations(t)
+}
+
 func TestCreateOrder_InvalidInput(t *testing.T) {
 	mockSvc := new(MockOrderService)
 	router, _ := setupTest(mockSvc)
@@ -134,7 +452,7 @@ func TestCreateOrder_ServiceError(t *testing.T) {
 
 	// 1. Arrange: Mock Service GAGAL (simulasi produk tidak ditemukan, dll)
 	svcErr := errors.New("produk tidak ditemukan")
-	mockSvc.On("CreateOrder", reqBody).Return(nil, svcErr).Once()
+	mockSvc.On("CreateOrder", mock.Anything, reqBody).Return(nil, svcErr).Once()
 
 	// 2. Act
 	reqBodyJSON, _ := json.Marshal(reqBody)
@@ -148,3 +466,183 @@ func TestCreateOrder_ServiceError(t *testing.T) {
 
 	mockSvc.AssertExpectations(t)
 }
+
+// --- TEST CASES: GET /orders/product/:productID ---
+
+func TestGetOrdersByProductID_PaginationAndFilters(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router, _ := setupTest(mockSvc)
+
+	productID := uuid.New()
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cursor := order.OrderCursor{CreatedAt: from.Add(time.Hour), ID: uuid.New()}
+	expectedQuery := order.OrderListQuery{
+		Status:      order.StatusPending,
+		CreatedFrom: &from,
+		Limit:       2,
+		Cursor:      &order.OrderCursor{CreatedAt: cursor.CreatedAt, ID: cursor.ID},
+	}
+
+	page := &order.OrderPage{
+		Orders:     []order.Order{{ID: uuid.New(), ProductID: productID}, {ID: uuid.New(), ProductID: productID}},
+		NextCursor: "next-page",
+	}
+	mockSvc.On("GetOrdersByProductID", mock.Anything, productID, mock.MatchedBy(func(q order.OrderListQuery) bool {
+		return q.Status == expectedQuery.Status && q.Limit == expectedQuery.Limit &&
+			q.CreatedFrom.Equal(from) && q.CreatedTo == nil &&
+			q.Cursor.ID == cursor.ID && q.Cursor.CreatedAt.Equal(cursor.CreatedAt)
+	})).Return(page, nil).Once()
+
+	w := httptest.NewRecorder()
+	url := "/orders/product/" + productID.String() + "?limit=2&status=pending&from=2024-01-01T00:00:00Z&cursor=" + cursor.Encode()
+	req, _ := http.NewRequest("GET", url, nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "next-page", w.Header().Get(NextCursorHeader))
+
+	var responseBody []map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &responseBody))
+	assert.Len(t, responseBody, 2)
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetOrdersByProductID_InvalidQuery(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router, _ := setupTest(mockSvc)
+
+	productID := uuid.New().String()
+	// Format salah ditolak handler sebelum service dipanggil
+	for _, query := range []string{"limit=abc", "from=yesterday", "cursor=not-a-cursor"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/orders/product/"+productID+"?"+query, nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		var resp ErrorResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, CodeInvalidRequest, resp.Code, query)
+	}
+	mockSvc.AssertNotCalled(t, "GetOrdersByProductID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetOrdersByProductID_QueryOutOfRange(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router, _ := setupTest(mockSvc)
+
+	// Nilai di luar batas divalidasi service (Normalize); status dan kodenya sama dengan format salah
+	productID := uuid.New()
+	from := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for raw, query := range map[string]order.OrderListQuery{
+		"limit=101": {Limit: 101},
+		"limit=-1":  {Limit: -1},
+		"from=2024-02-01T00:00:00Z&to=2024-01-01T00:00:00Z": {CreatedFrom: &from, CreatedTo: &to},
+	} {
+		_, normalizeErr := query.Normalize()
+		mockSvc.On("GetOrdersByProductID", mock.Anything, productID, mock.Anything).Return(nil, normalizeErr).Once()
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/orders/product/"+productID.String()+"?"+raw, nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, raw)
+		var resp ErrorResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, CodeInvalidRequest, resp.Code, raw)
+	}
+	mockSvc.AssertExpectations(t)
+}
+
+// --- TEST CASES: GET /orders/:id ---
+
+func TestGetOrderByID_Success(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router, _ := setupTest(mockSvc)
+
+	orderID := uuid.New()
+	mockSvc.On("GetOrderByID", mock.Anything, orderID).Return(&order.Order{ID: orderID}, nil).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/orders/"+orderID.String(), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var responseBody map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &responseBody))
+	assert.Equal(t, orderID.String(), responseBody["id"])
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestGetOrderByID_NotFound(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router, _ := setupTest(mockSvc)
+
+	orderID := uuid.New()
+	mockSvc.On("GetOrderByID", mock.Anything, orderID).Return(nil, order.ErrOrderNotFound).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/orders/"+orderID.String(), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockSvc.AssertExpectations(t)
+}
+
+// --- TEST CASES: PATCH /orders/:id/status ---
+
+func TestUpdateOrderStatus_Success(t *testing.T) {
+	mockSvc := new(MockOrderService)
+	router, _ := setupTest(mockSvc)
+
+	orderID := uuid.New()
+	updatedOrder := &order.Order{ID: orderID, Status: order.StatusProcessed}
+	mockSvc.On("UpdateOrderStatus", mock.Anything, orderID, order.StatusProcessed).Return(updatedOrder, nil).Once()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/orders/"+orderID.String()+"/status", bytes.NewBufferString(`{"status":"PROCESSED"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var responseBody map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &responseBody))
+	assert.Equal(t, string(order.StatusProcessed), responseBody["status"])
+
+	mockSvc.AssertExpectations(t)
+}
+
+func TestUpdateOrderStatus_ErrorMapping(t *testing.T) {
+	cases := []struct {
+		name     string
+		svcErr   error
+		expected int
+	}{
+		{"unknown status", order.ErrInvalidStatus, http.StatusBadRequest},
+		{"order not found", order.ErrOrderNotFound, http.StatusNotFound},
+		{"illegal transition", order.ErrInvalidStatusTransition, http.StatusConflict},
+		{"unexpected error", errors.New("db down"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockSvc := new(MockOrderService)
+			router, _ := setupTest(mockSvc)
+
+			orderID := uuid.New()
+			mockSvc.On("UpdateOrderStatus", mock.Anything, orderID, order.StatusFailed).Return(nil, tc.svcErr).Once()
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", "/orders/"+orderID.String()+"/status", bytes.NewBufferString(`{"status":"FAILED"}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expected, w.Code)
+			mockSvc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package order
+
+import "time"
+
+// Status pemrosesan sebuah Idempotency-Key
+type IdempotencyState string
+
+const (
+	IdempotencyInProgress IdempotencyState = "IN_PROGRESS"
+	IdempotencyCompleted  IdempotencyState = "COMPLETED"
+)
+
+// IdempotencyRecord menyimpan respons pertama untuk sebuah Idempotency-Key.
+// Disimpan di Redis (dengan TTL) dan di tabel 'idempotency_keys' sebagai fallback.
+type IdempotencyRecord struct {
+	Key          string           `gorm:"column:idempotency_key;type:varchar(255);primary_key" json:"key"`
+	Fingerprint  string           `gorm:"type:varchar(64);not null" json:"fingerprint"` // SHA-256 body request
+	State        IdempotencyState `gorm:"type:varchar(20);not null" json:"state"`
+	StatusCode   int              `json:"status_code"`
+	ResponseBody []byte           `json:"response_body"`
+	ExpiresAt    time.Time        `gorm:"not null;index" json:"expires_at"`
+	CreatedAt    time.Time        `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_keys"
+}
+
+// IsExpired bernilai true jika record sudah melewati masa berlakunya
+func (r *IdempotencyRecord) IsExpired(now time.Time) bool {
+	return !r.ExpiresAt.After(now)
+}
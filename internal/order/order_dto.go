@@ -2,22 +2,65 @@
 package order
 
 import (
-	"time"
+	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 )
 
-// Payload JSON untuk POST /orders
+// MaxOrderItems membatasi jumlah baris dalam satu order
+const MaxOrderItems = 50
+
+// ErrInvalidOrderRequest dikembalikan untuk payload order yang tidak valid secara bisnis
+var ErrInvalidOrderRequest = errors.New("request order tidak valid")
+
+// Payload JSON untuk POST /orders.
+// Gunakan 'items' untuk order multi-produk, atau productId + quantity (format lama)
+// untuk order satu produk. Keduanya tidak boleh dipakai bersamaan.
 type CreateOrderRequest struct {
+	ProductID uuid.UUID                `json:"productId" binding:"required_without=Items"`
+	Quantity  int                      `json:"quantity" binding:"required_without=Items,omitempty,min=1"`
+	Items     []CreateOrderItemRequest `json:"items,omitempty" binding:"omitempty,max=50,dive"`
+}
+
+// Satu baris di dalam 'items'
+type CreateOrderItemRequest struct {
 	ProductID uuid.UUID `json:"productId" binding:"required"`
 	Quantity  int       `json:"quantity" binding:"required,min=1"`
 }
 
-// Response JSON untuk order yang berhasil dibuat
-type OrderResponse struct {
-	ID         uuid.UUID   `json:"id"`
-	ProductID  uuid.UUID   `json:"productId"`
-	TotalPrice float64     `json:"totalPrice"`
-	Status     OrderStatus `json:"status"`
-	CreatedAt  time.Time   `json:"createdAt"`
+// Lines menormalkan request menjadi daftar baris order. Produk yang muncul lebih
+// dari sekali digabung (quantity dijumlah) agar pengecekan stok tidak terlewat.
+func (r CreateOrderRequest) Lines() ([]CreateOrderItemRequest, error) {
+	if len(r.Items) > 0 && r.ProductID != uuid.Nil {
+		return nil, fmt.Errorf("%w: gunakan 'items' atau 'productId', tidak keduanya", ErrInvalidOrderRequest)
+	}
+
+	raw := r.Items
+	if len(raw) == 0 {
+		raw = []CreateOrderItemRequest{{ProductID: r.ProductID, Quantity: r.Quantity}}
+	}
+	if len(raw) > MaxOrderItems {
+		return nil, fmt.Errorf("%w: maksimal %d item per order", ErrInvalidOrderRequest, MaxOrderItems)
+	}
+
+	var lines []CreateOrderItemRequest
+	index := map[uuid.UUID]int{}
+	for _, item := range raw {
+		if item.ProductID == uuid.Nil || item.Quantity < 1 {
+			return nil, fmt.Errorf("%w: setiap item wajib punya productId dan quantity >= 1", ErrInvalidOrderRequest)
+		}
+		if i, ok := index[item.ProductID]; ok {
+			lines[i].Quantity += item.Quantity
+			continue
+		}
+		index[item.ProductID] = len(lines)
+		lines = append(lines, item)
+	}
+	return lines, nil
+}
+
+// Payload JSON untuk PATCH /orders/:id/status
+type UpdateOrderStatusRequest struct {
+	Status OrderStatus `json:"status" binding:"required"`
 }
@@ -0,0 +1,38 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateOrderRequest_Lines(t *testing.T) {
+	productA := uuid.New()
+	productB := uuid.New()
+
+	// Format lama (satu produk) menjadi satu baris
+	lines, err := CreateOrderRequest{ProductID: productA, Quantity: 2}.Lines()
+	assert.NoError(t, err)
+	assert.Equal(t, []CreateOrderItemRequest{{ProductID: productA, Quantity: 2}}, lines)
+
+	// Produk yang sama digabung, urutan kemunculan pertama dipertahankan
+	lines, err = CreateOrderRequest{Items: []CreateOrderItemRequest{
+		{ProductID: productA, Quantity: 1},
+		{ProductID: productB, Quantity: 4},
+		{ProductID: productA, Quantity: 2},
+	}}.Lines()
+	assert.NoError(t, err)
+	assert.Equal(t, []CreateOrderItemRequest{
+		{ProductID: productA, Quantity: 3},
+		{ProductID: productB, Quantity: 4},
+	}, lines)
+
+	// productId dan items tidak boleh dipakai bersamaan
+	_, err = CreateOrderRequest{ProductID: productA, Quantity: 1, Items: []CreateOrderItemRequest{{ProductID: productB, Quantity: 1}}}.Lines()
+	assert.ErrorIs(t, err, ErrInvalidOrderRequest)
+
+	// items kosong tanpa productId ditolak
+	_, err = CreateOrderRequest{Items: []CreateOrderItemRequest{}}.Lines()
+	assert.ErrorIs(t, err, ErrInvalidOrderRequest)
+}
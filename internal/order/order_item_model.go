@@ -0,0 +1,52 @@
+package order
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OrderItem adalah model GORM untuk tabel 'order_items' (satu baris per produk dalam order)
+type OrderItem struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
+	OrderID     uuid.UUID `gorm:"type:uuid;not null;index" json:"order_id"`
+	ProductID   uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
+	ProductName string    `gorm:"type:varchar(255);not null" json:"product_name"`
+	Quantity    int       `gorm:"not null" json:"quantity"`
+	UnitPrice   float64   `gorm:"type:decimal(10,2);not null" json:"unit_price"`
+	Subtotal    float64   `gorm:"type:decimal(10,2);not null" json:"subtotal"`
+	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+}
+
+// Hook GORM untuk membuat UUID baru sebelum create
+func (item *OrderItem) BeforeCreate(tx *gorm.DB) (err error) {
+	if item.ID == uuid.Nil {
+		item.ID = uuid.New()
+	}
+	return
+}
+
+// ProductIDs mengembalikan semua produk (unik) yang ada di order,
+// termasuk ProductID level order untuk order lama tanpa item.
+func (order *Order) ProductIDs() []uuid.UUID {
+	seen := map[uuid.UUID]struct{}{}
+	var ids []uuid.UUID
+
+	add := func(id uuid.UUID) {
+		if id == uuid.Nil {
+			return
+		}
+		if _, ok := seen[id]; ok {
+			return
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+	}
+
+	add(order.ProductID)
+	for _, item := range order.Items {
+		add(item.ProductID)
+	}
+	return ids
+}
@@ -20,10 +20,20 @@ const (
 type Order struct {
 	// PENAMBAHAN JSON TAG UNTUK FIX TEST FAILURE
 	ID         uuid.UUID   `gorm:"type:uuid;primary_key;" json:"id"`
-	ProductID  uuid.UUID   `gorm:"type:uuid;not null" json:"product_id"`
+	ProductID  uuid.UUID   `gorm:"type:uuid;not null;index:idx_orders_product_created,priority:1" json:"product_id"`
 	TotalPrice float64     `gorm:"type:decimal(10,2);not null" json:"total_price"`
 	Status     OrderStatus `gorm:"type:varchar(50);not null" json:"status"`
-	CreatedAt  time.Time   `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	CreatedAt  time.Time   `gorm:"default:CURRENT_TIMESTAMP;index:idx_orders_product_created,priority:2,sort:desc" json:"created_at"`
+
+	// Snapshot data produk saat order dibuat (untuk audit & re-emit event).
+	// Default 0/'' hanya untuk baris lama yang dibuat sebelum kolom ini ada.
+	// Untuk order multi-item, ProductID dan snapshot ini mengacu ke item pertama
+	// (kompatibilitas API lama); rincian lengkap ada di Items.
+	Quantity    int     `gorm:"not null;default:0" json:"quantity"`
+	UnitPrice   float64 `gorm:"type:decimal(10,2);not null;default:0" json:"unit_price"`
+	ProductName string  `gorm:"type:varchar(255);not null;default:''" json:"product_name"`
+
+	Items []OrderItem `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
 }
 
 // Hook GORM untuk membuat UUID baru sebelum create
@@ -24,3 +24,21 @@ func TestOrder_BeforeCreate(t *testing.T) {
 	assert.NoError(t, err)                 // Pastikan tidak ada error
 	assert.NotEqual(t, uuid.Nil, order.ID) // Pastikan ID telah di-generate
 }
+
+func TestOrder_TransitionTo(t *testing.T) {
+	// PENDING -> PROCESSED diizinkan
+	order := &Order{Status: StatusPending}
+	assert.NoError(t, order.TransitionTo(StatusProcessed))
+	assert.Equal(t, StatusProcessed, order.Status)
+
+	// PROCESSED adalah status akhir, tidak bisa pindah lagi
+	err := order.TransitionTo(StatusFailed)
+	assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+	assert.Equal(t, StatusProcessed, order.Status)
+
+	// Status yang tidak dikenal ditolak
+	order = &Order{Status: StatusPending}
+	err = order.TransitionTo(OrderStatus("SHIPPED"))
+	assert.ErrorIs(t, err, ErrInvalidStatus)
+	assert.Equal(t, StatusPending, order.Status)
+}
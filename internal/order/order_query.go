@@ -0,0 +1,110 @@
+package order
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ErrInvalidCursor dikembalikan untuk cursor pagination yang rusak
+var ErrInvalidCursor = errors.New("cursor tidak valid")
+
+// ErrInvalidOrderQuery dikembalikan untuk query string daftar order yang tidak valid
+// (format salah maupun nilai di luar batas diperlakukan sama)
+var ErrInvalidOrderQuery = errors.New("query daftar order tidak valid")
+
+// OrderCursor menunjuk order terakhir di halaman sebelumnya.
+// Urutan halaman: created_at DESC, id DESC.
+type OrderCursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// Encode membuat cursor opaque (base64url) untuk dikirim ke klien
+func (c OrderCursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodeOrderCursor membaca cursor hasil OrderCursor.Encode
+func DecodeOrderCursor(s string) (*OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &OrderCursor{CreatedAt: createdAt, ID: id}, nil
+}
+
+// OrderListQuery adalah filter + pagination untuk daftar order per produk
+type OrderListQuery struct {
+	Status      OrderStatus  // kosong = semua status
+	CreatedFrom *time.Time   // inklusif
+	CreatedTo   *time.Time   // eksklusif
+	Limit       int          // 0 = DefaultPageLimit
+	Cursor      *OrderCursor // nil = halaman pertama
+}
+
+// Normalize mengisi default dan memvalidasi query
+func (q OrderListQuery) Normalize() (OrderListQuery, error) {
+	if q.Limit == 0 {
+		q.Limit = DefaultPageLimit
+	}
+	if q.Limit < 1 || q.Limit > MaxPageLimit {
+		return q, fmt.Errorf("%w: limit harus antara 1 dan %d", ErrInvalidOrderQuery, MaxPageLimit)
+	}
+	if q.Status != "" && !q.Status.IsValid() {
+		return q, fmt.Errorf("%w: %q", ErrInvalidStatus, q.Status)
+	}
+	if q.CreatedFrom != nil && q.CreatedTo != nil && !q.CreatedFrom.Before(*q.CreatedTo) {
+		return q, fmt.Errorf("%w: 'from' harus sebelum 'to'", ErrInvalidOrderQuery)
+	}
+	return q, nil
+}
+
+// CacheField adalah representasi kanonik query, dipakai sebagai field cache Redis
+func (q OrderListQuery) CacheField() string {
+	v := url.Values{}
+	v.Set("limit", strconv.Itoa(q.Limit))
+	if q.Status != "" {
+		v.Set("status", string(q.Status))
+	}
+	if q.CreatedFrom != nil {
+		v.Set("from", q.CreatedFrom.UTC().Format(time.RFC3339Nano))
+	}
+	if q.CreatedTo != nil {
+		v.Set("to", q.CreatedTo.UTC().Format(time.RFC3339Nano))
+	}
+	if q.Cursor != nil {
+		v.Set("cursor", q.Cursor.Encode())
+	}
+	return v.Encode()
+}
+
+// OrderPage adalah satu halaman hasil daftar order
+type OrderPage struct {
+	Orders     []Order `json:"orders"`
+	NextCursor string  `json:"nextCursor,omitempty"` // kosong = halaman terakhir
+}
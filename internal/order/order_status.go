@@ -0,0 +1,55 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error domain untuk siklus hidup status order
+var (
+	ErrOrderNotFound           = errors.New("order tidak ditemukan")
+	ErrInvalidStatus           = errors.New("status order tidak dikenal")
+	ErrInvalidStatusTransition = errors.New("transisi status order tidak diizinkan")
+)
+
+// allowedTransitions adalah state machine status order.
+// PENDING adalah status awal, PROCESSED dan FAILED adalah status akhir (terminal).
+var allowedTransitions = map[OrderStatus][]OrderStatus{
+	StatusPending:   {StatusProcessed, StatusFailed},
+	StatusProcessed: {},
+	StatusFailed:    {},
+}
+
+// IsValid memastikan status termasuk salah satu status yang dikenal
+func (s OrderStatus) IsValid() bool {
+	_, ok := allowedTransitions[s]
+	return ok
+}
+
+// IsTerminal bernilai true jika status tidak bisa berpindah ke status lain
+func (s OrderStatus) IsTerminal() bool {
+	return s.IsValid() && len(allowedTransitions[s]) == 0
+}
+
+// CanTransitionTo mengecek apakah perpindahan s -> next diizinkan
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range allowedTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTo memindahkan status order ke 'next' jika diizinkan state machine.
+// Status order tidak diubah jika transisi ditolak.
+func (order *Order) TransitionTo(next OrderStatus) error {
+	if !next.IsValid() {
+		return fmt.Errorf("%w: %q", ErrInvalidStatus, next)
+	}
+	if !order.Status.CanTransitionTo(next) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, order.Status, next)
+	}
+	order.Status = next
+	return nil
+}
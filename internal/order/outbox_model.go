@@ -0,0 +1,51 @@
+package order
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Status baris outbox
+type OutboxStatus string
+
+const (
+	OutboxStatusPending OutboxStatus = "PENDING"
+	OutboxStatusSent    OutboxStatus = "SENT"
+	// OutboxStatusFailed: batas percobaan habis, event tidak dikirim lagi oleh relay
+	OutboxStatusFailed OutboxStatus = "FAILED"
+)
+
+// OutboxEvent adalah model GORM untuk tabel 'outbox_events'.
+// Baris ini ditulis dalam transaksi yang sama dengan order, lalu di-publish
+// ke RabbitMQ oleh relay di background (transactional outbox pattern).
+type OutboxEvent struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;" json:"id"`
+	AggregateID uuid.UUID `gorm:"type:uuid;not null;index" json:"aggregate_id"`
+	Exchange    string    `gorm:"type:varchar(255);not null" json:"exchange"`
+	RoutingKey  string    `gorm:"type:varchar(255);not null" json:"routing_key"`
+	Payload     []byte    `gorm:"not null" json:"payload"`
+	// Headers berisi trace context dan request ID dari request yang menulis event
+	Headers       map[string]string `gorm:"serializer:json;type:text" json:"headers,omitempty"`
+	Status        OutboxStatus      `gorm:"type:varchar(20);not null;index:idx_outbox_pending,priority:1" json:"status"`
+	Attempts      int               `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt time.Time         `gorm:"not null;index:idx_outbox_pending,priority:2" json:"next_attempt_at"`
+	LastError     string            `gorm:"type:text" json:"last_error"`
+	CreatedAt     time.Time         `gorm:"default:CURRENT_TIMESTAMP" json:"created_at"`
+	SentAt        *time.Time        `json:"sent_at"`
+}
+
+// Hook GORM untuk mengisi ID, status dan jadwal kirim default
+func (event *OutboxEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.Status == "" {
+		event.Status = OutboxStatusPending
+	}
+	if event.NextAttemptAt.IsZero() {
+		event.NextAttemptAt = time.Now()
+	}
+	return
+}
@@ -0,0 +1,59 @@
+// internal/order/repository/idempotency_repository.go
+package repository
+
+import (
+	"challenge-order-service/internal/order"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyRepository adalah fallback DB untuk penyimpanan Idempotency-Key
+type IdempotencyRepository interface {
+	// Insert mengembalikan false (tanpa error) jika key sudah ada
+	Insert(ctx context.Context, record *order.IdempotencyRecord) (bool, error)
+	// Find mengembalikan nil (tanpa error) jika key tidak ada
+	Find(ctx context.Context, key string) (*order.IdempotencyRecord, error)
+	// Upsert menulis record, menimpa record lama dengan key yang sama
+	Upsert(ctx context.Context, record *order.IdempotencyRecord) error
+	Delete(ctx context.Context, key string) error
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Insert(ctx context.Context, record *order.IdempotencyRecord) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *idempotencyRepository) Find(ctx context.Context, key string) (*order.IdempotencyRecord, error) {
+	var record order.IdempotencyRecord
+
+	err := r.db.WithContext(ctx).First(&record, "idempotency_key = ?", key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *idempotencyRepository) Upsert(ctx context.Context, record *order.IdempotencyRecord) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(record).Error
+}
+
+func (r *idempotencyRepository) Delete(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Delete(&order.IdempotencyRecord{}, "idempotency_key = ?", key).Error
+}
@@ -0,0 +1,52 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"challenge-order-service/internal/order"
+	"challenge-order-service/internal/order/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyRepository_InsertFindUpsertDelete(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewIdempotencyRepository(db)
+
+	key := uuid.NewString()
+	record := &order.IdempotencyRecord{
+		Key:         key,
+		Fingerprint: "abc",
+		State:       order.IdempotencyInProgress,
+		ExpiresAt:   time.Now().Add(time.Minute),
+	}
+
+	// 1. Insert pertama berhasil, insert kedua dengan key sama ditolak tanpa error
+	inserted, err := repo.Insert(ctx, record)
+	assert.NoError(t, err)
+	assert.True(t, inserted)
+
+	inserted, err = repo.Insert(ctx, record)
+	assert.NoError(t, err)
+	assert.False(t, inserted)
+
+	// 2. Upsert menimpa record dengan respons final
+	record.State = order.IdempotencyCompleted
+	record.StatusCode = 201
+	record.ResponseBody = []byte(`{"id":"x"}`)
+	assert.NoError(t, repo.Upsert(ctx, record))
+
+	found, err := repo.Find(ctx, key)
+	assert.NoError(t, err)
+	assert.Equal(t, order.IdempotencyCompleted, found.State)
+	assert.Equal(t, 201, found.StatusCode)
+	assert.Equal(t, `{"id":"x"}`, string(found.ResponseBody))
+
+	// 3. Delete menghapus key
+	assert.NoError(t, repo.Delete(ctx, key))
+	found, err = repo.Find(ctx, key)
+	assert.NoError(t, err)
+	assert.Nil(t, found)
+}
@@ -4,17 +4,36 @@ package repository
 import (
 	// Impor struct Order dari folder model kita
 	"challenge-order-service/internal/order"
+	"context"
+	"errors"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// batchInsertSize membatasi jumlah baris per INSERT agar tidak melewati
+// batas parameter Postgres (65535) untuk batch yang besar.
+const batchInsertSize = 1000
+
 // 1. Definisikan "Kontrak" (Interface)
 type OrderRepository interface {
-	Save(order *order.Order) (*order.Order, error)
-	FindByProductID(productID uuid.UUID) ([]order.Order, error)
+	Save(ctx context.Context, order *order.Order) (*order.Order, error)
+	// SaveWithOutbox menyimpan order dan event outbox-nya dalam satu transaksi
+	SaveWithOutbox(ctx context.Context, order *order.Order, event *order.OutboxEvent) (*order.Order, error)
+	// SaveBatchWithOutbox menyimpan banyak order + event dengan INSERT multi-baris dalam satu transaksi
+	SaveBatchWithOutbox(ctx context.Context, orders []*order.Order, events []*order.OutboxEvent) error
+	FindByID(ctx context.Context, id uuid.UUID) (*order.Order, error)
+	// FindByProductID mengembalikan satu halaman order (created_at DESC, id DESC)
+	FindByProductID(ctx context.Context, productID uuid.UUID, query order.OrderListQuery) (*order.OrderPage, error)
+	// UpdateStatus mengembalikan order yang sudah diperbarui beserta status sebelumnya.
+	// Event dari newEvent (boleh nil) ditulis ke outbox dalam transaksi yang sama.
+	UpdateStatus(ctx context.Context, id uuid.UUID, status order.OrderStatus, newEvent StatusEventFunc) (*order.Order, order.OrderStatus, error)
 }
 
+// StatusEventFunc membangun event outbox dari order yang statusnya baru diubah
+type StatusEventFunc func(updated *order.Order, previous order.OrderStatus) *order.OutboxEvent
+
 // 2. Definisikan "Implementasi" (Struct)
 type orderRepository struct {
 	db *gorm.DB
@@ -26,19 +45,147 @@ func NewOrderRepository(db *gorm.DB) OrderRepository {
 }
 
 // 4. Implementasikan fungsi "Save" (untuk POST /orders)
-func (r *orderRepository) Save(order *order.Order) (*order.Order, error) {
-	if err := r.db.Create(order).Error; err != nil {
+func (r *orderRepository) Save(ctx context.Context, order *order.Order) (*order.Order, error) {
+	if err := r.db.WithContext(ctx).Create(order).Error; err != nil {
 		return nil, err
 	}
 	return order, nil
 }
 
+// 4b. Implementasikan fungsi "SaveWithOutbox" (transactional outbox untuk POST /orders)
+// Jika salah satu insert gagal, keduanya di-rollback sehingga tidak ada
+// order tanpa event (atau event tanpa order).
+func (r *orderRepository) SaveWithOutbox(ctx context.Context, newOrder *order.Order, event *order.OutboxEvent) (*order.Order, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(newOrder).Error; err != nil {
+			return err
+		}
+		event.AggregateID = newOrder.ID
+		return tx.Create(event).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newOrder, nil
+}
+
+// 4c. Implementasikan fungsi "SaveBatchWithOutbox" (mode POST /orders asinkron)
+// events[i] adalah event milik orders[i].
+func (r *orderRepository) SaveBatchWithOutbox(ctx context.Context, orders []*order.Order, events []*order.OutboxEvent) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(orders, batchInsertSize).Error; err != nil {
+			return err
+		}
+		for i, event := range events {
+			event.AggregateID = orders[i].ID
+		}
+		return tx.CreateInBatches(events, batchInsertSize).Error
+	})
+}
+
 // 5. Implementasikan fungsi "FindByProductID" (untuk GET /orders/product/:productid)
-func (r *orderRepository) FindByProductID(productID uuid.UUID) ([]order.Order, error) {
+// Pagination memakai keyset (created_at, id) sehingga halaman berikutnya tetap
+// konsisten walaupun order baru terus masuk.
+func (r *orderRepository) FindByProductID(ctx context.Context, productID uuid.UUID, query order.OrderListQuery) (*order.OrderPage, error) {
 	var orders []order.Order
 
-	if err := r.db.Where("product_id = ?", productID).Find(&orders).Error; err != nil {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = order.DefaultPageLimit
+	}
+
+	// Order cocok jika produk ada di level order (order lama) atau di salah satu item-nya
+	itemOrders := r.db.WithContext(ctx).Model(&order.OrderItem{}).Select("order_id").Where("product_id = ?", productID)
+	tx := r.db.WithContext(ctx).Preload("Items").
+		Where("product_id = ? OR id IN (?)", productID, itemOrders)
+
+	if query.Status != "" {
+		tx = tx.Where("status = ?", query.Status)
+	}
+	if query.CreatedFrom != nil {
+		tx = tx.Where("created_at >= ?", *query.CreatedFrom)
+	}
+	if query.CreatedTo != nil {
+		tx = tx.Where("created_at < ?", *query.CreatedTo)
+	}
+	if query.Cursor != nil {
+		tx = tx.Where("(created_at < ? OR (created_at = ? AND id < ?))",
+			query.Cursor.CreatedAt, query.Cursor.CreatedAt, query.Cursor.ID)
+	}
+
+	// Ambil satu baris ekstra untuk mengetahui apakah masih ada halaman berikutnya
+	err := tx.Order("created_at DESC").Order("id DESC").
+		Limit(limit + 1).
+		Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	page := &order.OrderPage{Orders: orders}
+	if len(orders) > limit {
+		page.Orders = orders[:limit]
+		last := page.Orders[limit-1]
+		page.NextCursor = order.OrderCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
+	}
+	return page, nil
+}
+
+// 6. Implementasikan fungsi "FindByID" (untuk GET /orders/:id)
+// Mengembalikan order.ErrOrderNotFound jika order tidak ada.
+func (r *orderRepository) FindByID(ctx context.Context, id uuid.UUID) (*order.Order, error) {
+	var found order.Order
+
+	err := r.db.WithContext(ctx).Preload("Items").First(&found, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, order.ErrOrderNotFound
+	}
+	if err != nil {
 		return nil, err
 	}
-	return orders, nil
+	return &found, nil
+}
+
+// 7. Implementasikan fungsi "UpdateStatus" (untuk PATCH /orders/:id/status)
+// Baris order dikunci (SELECT ... FOR UPDATE) di dalam transaksi agar dua
+// transisi yang berjalan bersamaan tidak saling menimpa. Event status ikut
+// di-commit bersama perubahan status, sama seperti 'order.created' di SaveWithOutbox.
+func (r *orderRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status order.OrderStatus, newEvent StatusEventFunc) (*order.Order, order.OrderStatus, error) {
+	var current order.Order
+	var previous order.OrderStatus
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Preload("Items").
+			First(&current, "id = ?", id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return order.ErrOrderNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		previous = current.Status
+		if err := current.TransitionTo(status); err != nil {
+			return err
+		}
+
+		err = tx.Model(&order.Order{}).
+			Where("id = ? AND status = ?", id, previous).
+			Update("status", current.Status).Error
+		if err != nil || newEvent == nil {
+			return err
+		}
+
+		event := newEvent(&current, previous)
+		event.AggregateID = current.ID
+		return tx.Create(event).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &current, previous, nil
 }
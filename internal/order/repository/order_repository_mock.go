@@ -3,6 +3,7 @@ package repository
 import (
 	"challenge-order-service/internal/order"
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -13,13 +14,14 @@ import (
 // Pastikan interface ini didefinisikan di order_repository.go
 type MockOrderRepository struct {
 	mock.Mock
+
+	// StatusEvents berisi event outbox yang ditulis UpdateStatus
+	StatusEvents []*order.OutboxEvent
 }
 
-// Save: Signature diperbaiki: TANPA CONTEXT dan mengembalikan (*order.Order, error).
-// Ini menghilangkan error 'too many arguments in call to s.repo.Save' yang lama.
-func (m *MockOrderRepository) Save(ord *order.Order) (*order.Order, error) {
-	// Panggilan mock juga tanpa context
-	args := m.Called(ord)
+// Save: menerima context (mengikuti OrderRepository) dan mengembalikan (*order.Order, error).
+func (m *MockOrderRepository) Save(ctx context.Context, ord *order.Order) (*order.Order, error) {
+	args := m.Called(ctx, ord)
 
 	result := args.Get(0)
 	if result == nil {
@@ -28,16 +30,61 @@ func (m *MockOrderRepository) Save(ord *order.Order) (*order.Order, error) {
 	return result.(*order.Order), args.Error(1)
 }
 
-// FindByProductID: Ditambahkan/Diganti dari GetOrdersByProductID.
-// Ini menghilangkan error 'missing method FindByProductID' di order_service_test.go.
-func (m *MockOrderRepository) FindByProductID(productID uuid.UUID) ([]order.Order, error) {
-	args := m.Called(productID)
+// SaveWithOutbox: mock untuk penyimpanan order + event outbox.
+func (m *MockOrderRepository) SaveWithOutbox(ctx context.Context, ord *order.Order, event *order.OutboxEvent) (*order.Order, error) {
+	args := m.Called(ctx, ord, event)
 
 	result := args.Get(0)
 	if result == nil {
 		return nil, args.Error(1)
 	}
-	return result.([]order.Order), args.Error(1)
+	return result.(*order.Order), args.Error(1)
+}
+
+// SaveBatchWithOutbox: mock untuk penyimpanan batch.
+func (m *MockOrderRepository) SaveBatchWithOutbox(ctx context.Context, orders []*order.Order, events []*order.OutboxEvent) error {
+	args := m.Called(ctx, orders, events)
+	return args.Error(0)
+}
+
+// FindByID: mock untuk pencarian satu order.
+func (m *MockOrderRepository) FindByID(ctx context.Context, id uuid.UUID) (*order.Order, error) {
+	args := m.Called(ctx, id)
+
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.(*order.Order), args.Error(1)
+}
+
+// FindByProductID: mock untuk daftar order per produk (dengan filter & pagination).
+func (m *MockOrderRepository) FindByProductID(ctx context.Context, productID uuid.UUID, query order.OrderListQuery) (*order.OrderPage, error) {
+	args := m.Called(ctx, productID, query)
+
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.(*order.OrderPage), args.Error(1)
+}
+
+// UpdateStatus: mock untuk transisi status order.
+// Jika berhasil, event dari newEvent dicatat di StatusEvents.
+func (m *MockOrderRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status order.OrderStatus, newEvent StatusEventFunc) (*order.Order, order.OrderStatus, error) {
+	args := m.Called(ctx, id, status)
+
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Get(1).(order.OrderStatus), args.Error(2)
+	}
+	updated, previous := result.(*order.Order), args.Get(1).(order.OrderStatus)
+	if args.Error(2) == nil && newEvent != nil {
+		event := newEvent(updated, previous)
+		event.AggregateID = updated.ID
+		m.StatusEvents = append(m.StatusEvents, event)
+	}
+	return updated, previous, args.Error(2)
 }
 
 // Catatan: Method GetOrdersByProductID yang lama dipertahankan di mock
@@ -52,3 +99,68 @@ func (m *MockOrderRepository) GetOrdersByProductID(ctx context.Context, productI
 	}
 	return result.([]order.Order), args.Error(1)
 }
+
+// MockOutboxRepository adalah mock untuk OutboxRepository
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) ClaimPending(ctx context.Context, limit int, now, leaseUntil time.Time) ([]order.OutboxEvent, error) {
+	args := m.Called(ctx, limit, now, leaseUntil)
+
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.([]order.OutboxEvent), args.Error(1)
+}
+
+func (m *MockOutboxRepository) MarkSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error {
+	args := m.Called(ctx, id, sentAt)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastErr string) error {
+	args := m.Called(ctx, id, attempts, nextAttemptAt, lastErr)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) MarkDead(ctx context.Context, id uuid.UUID, attempts int, lastErr string) error {
+	args := m.Called(ctx, id, attempts, lastErr)
+	return args.Error(0)
+}
+
+func (m *MockOutboxRepository) DeleteSentBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	args := m.Called(ctx, before, limit)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// MockIdempotencyRepository adalah mock untuk IdempotencyRepository
+type MockIdempotencyRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdempotencyRepository) Insert(ctx context.Context, record *order.IdempotencyRecord) (bool, error) {
+	args := m.Called(ctx, record)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockIdempotencyRepository) Find(ctx context.Context, key string) (*order.IdempotencyRecord, error) {
+	args := m.Called(ctx, key)
+
+	result := args.Get(0)
+	if result == nil {
+		return nil, args.Error(1)
+	}
+	return result.(*order.IdempotencyRecord), args.Error(1)
+}
+
+func (m *MockIdempotencyRepository) Upsert(ctx context.Context, record *order.IdempotencyRecord) error {
+	args := m.Called(ctx, record)
+	return args.Error(0)
+}
+
+func (m *MockIdempotencyRepository) Delete(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
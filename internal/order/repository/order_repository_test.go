@@ -1,9 +1,11 @@
 package repository_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
+	"challenge-order-service/internal/migrations"
 	// Impor struct Order dari direktori internal/order
 	"challenge-order-service/internal/order"
 	// Impor repository yang akan diuji
@@ -15,15 +17,20 @@ import (
 	"gorm.io/gorm"
 )
 
+// ctx dipakai oleh semua test repository
+var ctx = context.Background()
+
 // setupTestDB menginisialisasi database SQLite in-memory untuk pengujian
 func setupTestDB(t *testing.T) *gorm.DB {
 	// 1. Buka koneksi ke SQLite in-memory
 	db, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
 	assert.NoError(t, err, "Gagal membuka koneksi DB in-memory")
 
-	// 2. Melakukan AutoMigrate untuk membuat tabel Order
-	err = db.AutoMigrate(&order.Order{})
-	assert.NoError(t, err, "Gagal melakukan AutoMigrate untuk tabel Order")
+	// 2. Jalankan migrasi SQL yang sama dengan production (idempoten untuk DB shared)
+	migrator, err := migrations.NewMigrator(db)
+	assert.NoError(t, err, "Gagal memuat migrasi")
+	_, err = migrator.Up(ctx)
+	assert.NoError(t, err, "Gagal menjalankan migrasi")
 
 	return db
 }
@@ -46,7 +53,7 @@ func TestOrderRepository_Save_Success(t *testing.T) {
 	}
 
 	// 2. Act: Panggil metode Save
-	savedOrder, err := repo.Save(newOrder)
+	savedOrder, err := repo.Save(ctx, newOrder)
 
 	// 3. Assert
 	assert.NoError(t, err, "Save seharusnya tidak mengembalikan error")
@@ -61,6 +68,64 @@ func TestOrderRepository_Save_Success(t *testing.T) {
 	assert.Equal(t, newOrder.TotalPrice, fetchedOrder.TotalPrice)
 }
 
+func TestOrderRepository_Save_PersistsProductSnapshot(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOrderRepository(db)
+
+	savedOrder, err := repo.Save(ctx, &order.Order{
+		ProductID:   uuid.New(),
+		Quantity:    3,
+		UnitPrice:   12.50,
+		ProductName: "Laptop Demo",
+		TotalPrice:  37.50,
+		Status:      order.StatusPending,
+	})
+	assert.NoError(t, err)
+
+	fetchedOrder, err := repo.FindByID(ctx, savedOrder.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, fetchedOrder.Quantity)
+	assert.Equal(t, 12.50, fetchedOrder.UnitPrice)
+	assert.Equal(t, "Laptop Demo", fetchedOrder.ProductName)
+}
+
+// ====================================================================
+// TEST CASE: SaveBatchWithOutbox
+// ====================================================================
+func TestOrderRepository_SaveBatchWithOutbox_Success(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOrderRepository(db)
+
+	testProductID := uuid.New()
+	orders := []*order.Order{
+		{ID: uuid.New(), ProductID: testProductID, TotalPrice: 10.00, Status: order.StatusPending},
+		{ID: uuid.New(), ProductID: testProductID, TotalPrice: 20.00, Status: order.StatusPending, Items: []order.OrderItem{
+			{ProductID: testProductID, ProductName: "A", Quantity: 2, UnitPrice: 10, Subtotal: 20},
+		}},
+	}
+	events := []*order.OutboxEvent{
+		{Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`)},
+		{Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`)},
+	}
+
+	err := repo.SaveBatchWithOutbox(ctx, orders, events)
+
+	assert.NoError(t, err)
+	page, err := repo.FindByProductID(ctx, testProductID, order.OrderListQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, page.Orders, 2)
+
+	// Item ikut tersimpan bersama batch
+	found, err := repo.FindByID(ctx, orders[1].ID)
+	assert.NoError(t, err)
+	assert.Len(t, found.Items, 1)
+
+	// Setiap event harus menunjuk ke order pasangannya
+	for i, event := range events {
+		assert.Equal(t, orders[i].ID, event.AggregateID)
+	}
+}
+
 // ====================================================================
 // TEST CASE: FindByProductID
 // ====================================================================
@@ -79,12 +144,13 @@ func TestOrderRepository_FindByProductID_Success(t *testing.T) {
 	}
 
 	for _, o := range ordersToSave {
-		_, err := repo.Save(&o)
+		_, err := repo.Save(ctx, &o)
 		assert.NoError(t, err, "Gagal menyimpan order fixture")
 	}
 
 	// 2. Act: Cari order berdasarkan ProductID
-	foundOrders, err := repo.FindByProductID(testProductID)
+	page, err := repo.FindByProductID(ctx, testProductID, order.OrderListQuery{})
+	foundOrders := page.Orders
 
 	// 3. Assert
 	assert.NoError(t, err, "FindByProductID seharusnya tidak mengembalikan error")
@@ -95,6 +161,37 @@ func TestOrderRepository_FindByProductID_Success(t *testing.T) {
 	}
 }
 
+func TestOrderRepository_FindByProductID_MatchesOrderItems(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOrderRepository(db)
+
+	firstProductID := uuid.New()
+	secondProductID := uuid.New()
+
+	// Order multi-item: produk kedua hanya ada di order_items
+	saved, err := repo.Save(ctx, &order.Order{
+		ProductID:  firstProductID,
+		TotalPrice: 30.00,
+		Status:     order.StatusPending,
+		Items: []order.OrderItem{
+			{ProductID: firstProductID, ProductName: "A", Quantity: 1, UnitPrice: 10, Subtotal: 10},
+			{ProductID: secondProductID, ProductName: "B", Quantity: 2, UnitPrice: 10, Subtotal: 20},
+		},
+	})
+	assert.NoError(t, err)
+
+	page, err := repo.FindByProductID(ctx, secondProductID, order.OrderListQuery{})
+	assert.NoError(t, err)
+	foundOrders := page.Orders
+	assert.Len(t, foundOrders, 1)
+	assert.Equal(t, saved.ID, foundOrders[0].ID)
+	assert.Len(t, foundOrders[0].Items, 2, "Items harus ikut dimuat")
+
+	found, err := repo.FindByID(ctx, saved.ID)
+	assert.NoError(t, err)
+	assert.Len(t, found.Items, 2)
+}
+
 func TestOrderRepository_FindByProductID_NotFound(t *testing.T) {
 	db := setupTestDB(t)
 	repo := repository.NewOrderRepository(db)
@@ -103,9 +200,184 @@ func TestOrderRepository_FindByProductID_NotFound(t *testing.T) {
 	nonExistentProductID := uuid.New()
 
 	// 2. Act
-	foundOrders, err := repo.FindByProductID(nonExistentProductID)
+	page, err := repo.FindByProductID(ctx, nonExistentProductID, order.OrderListQuery{})
 
 	// 3. Assert
 	assert.NoError(t, err, "Tidak menemukan record seharusnya tidak dianggap error oleh Repository Find")
-	assert.Empty(t, foundOrders, "Seharusnya mengembalikan slice kosong jika tidak ditemukan")
+	assert.Empty(t, page.Orders, "Seharusnya mengembalikan slice kosong jika tidak ditemukan")
+	assert.Empty(t, page.NextCursor, "Tidak ada halaman berikutnya")
+}
+
+func TestOrderRepository_FindByProductID_CursorPagination(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOrderRepository(db)
+
+	testProductID := uuid.New()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// 1. Arrange: 5 order dengan created_at berurutan
+	var saved []uuid.UUID
+	for i := 0; i < 5; i++ {
+		o := &order.Order{ProductID: testProductID, TotalPrice: 10, Status: order.StatusPending, CreatedAt: base.Add(time.Duration(i) * time.Hour)}
+		_, err := repo.Save(ctx, o)
+		assert.NoError(t, err)
+		saved = append(saved, o.ID)
+	}
+
+	// 2. Act: baca per 2 order sampai habis
+	var seen []uuid.UUID
+	query := order.OrderListQuery{Limit: 2}
+	pages := 0
+	for {
+		page, err := repo.FindByProductID(ctx, testProductID, query)
+		assert.NoError(t, err)
+		pages++
+		for _, o := range page.Orders {
+			seen = append(seen, o.ID)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor, err = order.DecodeOrderCursor(page.NextCursor)
+		assert.NoError(t, err)
+	}
+
+	// 3. Assert: 3 halaman, terbaru dulu, tanpa duplikat
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []uuid.UUID{saved[4], saved[3], saved[2], saved[1], saved[0]}, seen)
+}
+
+func TestOrderRepository_FindByProductID_Filters(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOrderRepository(db)
+
+	testProductID := uuid.New()
+	base := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	fixtures := []order.Order{
+		{ProductID: testProductID, TotalPrice: 10, Status: order.StatusPending, CreatedAt: base},
+		{ProductID: testProductID, TotalPrice: 10, Status: order.StatusProcessed, CreatedAt: base.Add(24 * time.Hour)},
+		{ProductID: testProductID, TotalPrice: 10, Status: order.StatusPending, CreatedAt: base.Add(48 * time.Hour)},
+	}
+	for i := range fixtures {
+		_, err := repo.Save(ctx, &fixtures[i])
+		assert.NoError(t, err)
+	}
+
+	// Filter status
+	page, err := repo.FindByProductID(ctx, testProductID, order.OrderListQuery{Status: order.StatusPending})
+	assert.NoError(t, err)
+	assert.Len(t, page.Orders, 2)
+
+	// Filter rentang tanggal [from, to)
+	from := base.Add(time.Hour)
+	to := base.Add(48 * time.Hour)
+	page, err = repo.FindByProductID(ctx, testProductID, order.OrderListQuery{CreatedFrom: &from, CreatedTo: &to})
+	assert.NoError(t, err)
+	assert.Len(t, page.Orders, 1)
+	assert.Equal(t, fixtures[1].ID, page.Orders[0].ID)
+}
+
+// ====================================================================
+// TEST CASE: FindByID
+// ====================================================================
+func TestOrderRepository_FindByID_Success(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOrderRepository(db)
+
+	saved, err := repo.Save(ctx, &order.Order{ProductID: uuid.New(), TotalPrice: 42.50, Status: order.StatusPending})
+	assert.NoError(t, err)
+
+	found, err := repo.FindByID(ctx, saved.ID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, saved.ID, found.ID)
+	assert.Equal(t, saved.TotalPrice, found.TotalPrice)
+}
+
+func TestOrderRepository_FindByID_NotFound(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOrderRepository(db)
+
+	found, err := repo.FindByID(ctx, uuid.New())
+
+	assert.ErrorIs(t, err, order.ErrOrderNotFound)
+	assert.Nil(t, found)
+}
+
+// ====================================================================
+// TEST CASE: UpdateStatus
+// ====================================================================
+func TestOrderRepository_UpdateStatus_Success(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOrderRepository(db)
+
+	saved, err := repo.Save(ctx, &order.Order{ProductID: uuid.New(), TotalPrice: 10.00, Status: order.StatusPending})
+	assert.NoError(t, err)
+
+	updated, previous, err := repo.UpdateStatus(ctx, saved.ID, order.StatusProcessed, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, order.StatusPending, previous)
+	assert.Equal(t, order.StatusProcessed, updated.Status)
+
+	var fetchedOrder order.Order
+	assert.NoError(t, db.First(&fetchedOrder, "id = ?", saved.ID).Error)
+	assert.Equal(t, order.StatusProcessed, fetchedOrder.Status)
+}
+
+func TestOrderRepository_UpdateStatus_WritesOutboxEvent(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOrderRepository(db)
+
+	saved, err := repo.Save(ctx, &order.Order{ProductID: uuid.New(), TotalPrice: 10.00, Status: order.StatusPending})
+	assert.NoError(t, err)
+
+	_, _, err = repo.UpdateStatus(ctx, saved.ID, order.StatusProcessed, func(updated *order.Order, previous order.OrderStatus) *order.OutboxEvent {
+		return &order.OutboxEvent{
+			Exchange:   "orders_exchange",
+			RoutingKey: "order.processed",
+			Payload:    []byte(string(previous) + "->" + string(updated.Status)),
+		}
+	})
+	assert.NoError(t, err)
+
+	var events []order.OutboxEvent
+	assert.NoError(t, db.Find(&events, "aggregate_id = ?", saved.ID).Error)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, saved.ID, events[0].AggregateID)
+		assert.Equal(t, "order.processed", events[0].RoutingKey)
+		assert.Equal(t, "PENDING->PROCESSED", string(events[0].Payload))
+		assert.Equal(t, order.OutboxStatusPending, events[0].Status)
+	}
+}
+
+func TestOrderRepository_UpdateStatus_InvalidTransition(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOrderRepository(db)
+
+	saved, err := repo.Save(ctx, &order.Order{ProductID: uuid.New(), TotalPrice: 10.00, Status: order.StatusFailed})
+	assert.NoError(t, err)
+
+	_, _, err = repo.UpdateStatus(ctx, saved.ID, order.StatusProcessed, func(*order.Order, order.OrderStatus) *order.OutboxEvent {
+		return &order.OutboxEvent{Exchange: "orders_exchange", RoutingKey: "order.processed", Payload: []byte("{}")}
+	})
+	assert.ErrorIs(t, err, order.ErrInvalidStatusTransition)
+
+	// Status di DB tidak boleh berubah dan tidak ada event yang ditulis
+	var fetchedOrder order.Order
+	assert.NoError(t, db.First(&fetchedOrder, "id = ?", saved.ID).Error)
+	assert.Equal(t, order.StatusFailed, fetchedOrder.Status)
+
+	var count int64
+	assert.NoError(t, db.Model(&order.OutboxEvent{}).Where("aggregate_id = ?", saved.ID).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestOrderRepository_UpdateStatus_NotFound(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOrderRepository(db)
+
+	_, _, err := repo.UpdateStatus(ctx, uuid.New(), order.StatusProcessed, nil)
+	assert.ErrorIs(t, err, order.ErrOrderNotFound)
 }
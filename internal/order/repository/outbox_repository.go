@@ -0,0 +1,102 @@
+// internal/order/repository/outbox_repository.go
+package repository
+
+import (
+	"challenge-order-service/internal/order"
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxRepository adalah kontrak akses tabel 'outbox_events' untuk relay
+type OutboxRepository interface {
+	ClaimPending(ctx context.Context, limit int, now, leaseUntil time.Time) ([]order.OutboxEvent, error)
+	MarkSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error
+	MarkFailed(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastErr string) error
+	MarkDead(ctx context.Context, id uuid.UUID, attempts int, lastErr string) error
+	DeleteSentBefore(ctx context.Context, before time.Time, limit int) (int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// ClaimPending mengambil event PENDING yang jadwal kirimnya sudah lewat (urut dari yang
+// terlama) lalu menunda jadwalnya sampai leaseUntil, dalam satu transaksi. Di PostgreSQL
+// baris dikunci dengan FOR UPDATE SKIP LOCKED, sehingga relay di replika lain tidak
+// mengambil event yang sama. Jika relay mati sebelum MarkSent/MarkFailed, event
+// diambil ulang setelah leaseUntil.
+func (r *outboxRepository) ClaimPending(ctx context.Context, limit int, now, leaseUntil time.Time) ([]order.OutboxEvent, error) {
+	var events []order.OutboxEvent
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", order.OutboxStatusPending, now).
+			Order("created_at ASC").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(events))
+		for i, event := range events {
+			ids[i] = event.ID
+		}
+		return tx.Model(&order.OutboxEvent{}).
+			Where("id IN ?", ids).
+			Update("next_attempt_at", leaseUntil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+func (r *outboxRepository) MarkSent(ctx context.Context, id uuid.UUID, sentAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&order.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":  order.OutboxStatusSent,
+			"sent_at": sentAt,
+		}).Error
+}
+
+func (r *outboxRepository) MarkFailed(ctx context.Context, id uuid.UUID, attempts int, nextAttemptAt time.Time, lastErr string) error {
+	return r.db.WithContext(ctx).Model(&order.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastErr,
+		}).Error
+}
+
+// MarkDead menandai event FAILED setelah batas percobaan habis; relay tidak mengambilnya lagi
+func (r *outboxRepository) MarkDead(ctx context.Context, id uuid.UUID, attempts int, lastErr string) error {
+	return r.db.WithContext(ctx).Model(&order.OutboxEvent{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":     order.OutboxStatusFailed,
+			"attempts":   attempts,
+			"last_error": lastErr,
+		}).Error
+}
+
+// DeleteSentBefore menghapus maksimal limit event SENT yang dikirim sebelum before
+// dan mengembalikan jumlah baris yang terhapus
+func (r *outboxRepository) DeleteSentBefore(ctx context.Context, before time.Time, limit int) (int64, error) {
+	ids := r.db.Model(&order.OutboxEvent{}).
+		Select("id").
+		Where("status = ? AND sent_at < ?", order.OutboxStatusSent, before).
+		Limit(limit)
+	result := r.db.WithContext(ctx).Where("id IN (?)", ids).Delete(&order.OutboxEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,145 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"challenge-order-service/internal/order"
+	"challenge-order-service/internal/order/repository"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// ====================================================================
+// TEST CASE: SaveWithOutbox
+// ====================================================================
+func TestOrderRepository_SaveWithOutbox_Success(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOrderRepository(db)
+
+	newOrder := &order.Order{ProductID: uuid.New(), TotalPrice: 25.00, Status: order.StatusPending}
+	event := &order.OutboxEvent{
+		Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`),
+		Headers: map[string]string{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"},
+	}
+
+	saved, err := repo.SaveWithOutbox(ctx, newOrder, event)
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, saved.ID)
+
+	var fetchedEvent order.OutboxEvent
+	assert.NoError(t, db.First(&fetchedEvent, "aggregate_id = ?", saved.ID).Error)
+	assert.Equal(t, order.OutboxStatusPending, fetchedEvent.Status)
+	assert.Equal(t, "order.created", fetchedEvent.RoutingKey)
+	assert.Equal(t, event.Headers, fetchedEvent.Headers, "trace context ikut tersimpan")
+}
+
+func TestOrderRepository_SaveWithOutbox_RollbackOnEventFailure(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOrderRepository(db)
+
+	// Event dengan ID yang sudah dipakai akan gagal di-insert (primary key bentrok)
+	existing := &order.OutboxEvent{Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`)}
+	assert.NoError(t, db.Create(existing).Error)
+
+	newOrder := &order.Order{ProductID: uuid.New(), TotalPrice: 25.00, Status: order.StatusPending}
+	duplicate := &order.OutboxEvent{ID: existing.ID, Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`)}
+
+	_, err := repo.SaveWithOutbox(ctx, newOrder, duplicate)
+	assert.Error(t, err)
+
+	// Order tidak boleh tersimpan tanpa event-nya
+	var count int64
+	db.Model(&order.Order{}).Where("id = ?", newOrder.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+// ====================================================================
+// TEST CASE: OutboxRepository
+// ====================================================================
+func TestOutboxRepository_ClaimPending_MarkSent_MarkFailed(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOutboxRepository(db)
+	now := time.Now()
+
+	// Bersihkan sisa data dari test lain (DB in-memory dipakai bersama)
+	db.Where("1 = 1").Delete(&order.OutboxEvent{})
+
+	due := &order.OutboxEvent{Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`), NextAttemptAt: now.Add(-time.Second)}
+	later := &order.OutboxEvent{Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`), NextAttemptAt: now.Add(time.Hour)}
+	assert.NoError(t, db.Create(due).Error)
+	assert.NoError(t, db.Create(later).Error)
+
+	// Hanya event yang jadwalnya sudah lewat yang diambil
+	pending, err := repo.ClaimPending(ctx, 10, now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, due.ID, pending[0].ID)
+
+	// Event yang sudah diklaim tidak diambil relay lain sampai klaimnya habis
+	pending, err = repo.ClaimPending(ctx, 10, now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+	pending, err = repo.ClaimPending(ctx, 10, now.Add(2*time.Minute), now.Add(3*time.Minute))
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1, "klaim yang habis (relay mati) diambil ulang")
+
+	// MarkFailed menunda event dan mencatat error
+	assert.NoError(t, repo.MarkFailed(ctx, due.ID, 1, now.Add(10*time.Minute), "broker down"))
+	pending, err = repo.ClaimPending(ctx, 10, now.Add(5*time.Minute), now.Add(6*time.Minute))
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// MarkSent mengeluarkan event dari antrean
+	assert.NoError(t, repo.MarkSent(ctx, later.ID, now))
+	var fetched order.OutboxEvent
+	assert.NoError(t, db.First(&fetched, "id = ?", later.ID).Error)
+	assert.Equal(t, order.OutboxStatusSent, fetched.Status)
+	assert.NotNil(t, fetched.SentAt)
+}
+
+func TestOutboxRepository_MarkDead_DeleteSentBefore(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewOutboxRepository(db)
+	now := time.Now()
+
+	// Bersihkan sisa data dari test lain (DB in-memory dipakai bersama)
+	db.Where("1 = 1").Delete(&order.OutboxEvent{})
+
+	// Event FAILED tidak diklaim relay lagi
+	dead := &order.OutboxEvent{Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`), NextAttemptAt: now.Add(-time.Second)}
+	assert.NoError(t, db.Create(dead).Error)
+	assert.NoError(t, repo.MarkDead(ctx, dead.ID, 20, "unroutable"))
+	pending, err := repo.ClaimPending(ctx, 10, now, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+
+	var fetched order.OutboxEvent
+	assert.NoError(t, db.First(&fetched, "id = ?", dead.ID).Error)
+	assert.Equal(t, order.OutboxStatusFailed, fetched.Status)
+	assert.Equal(t, 20, fetched.Attempts)
+
+	// Hanya event SENT yang melewati batas yang dihapus, maksimal limit baris
+	old1 := &order.OutboxEvent{Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`)}
+	old2 := &order.OutboxEvent{Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`)}
+	recent := &order.OutboxEvent{Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`)}
+	for _, event := range []*order.OutboxEvent{old1, old2, recent} {
+		assert.NoError(t, db.Create(event).Error)
+	}
+	assert.NoError(t, repo.MarkSent(ctx, old1.ID, now.Add(-48*time.Hour)))
+	assert.NoError(t, repo.MarkSent(ctx, old2.ID, now.Add(-48*time.Hour)))
+	assert.NoError(t, repo.MarkSent(ctx, recent.ID, now))
+
+	deleted, err := repo.DeleteSentBefore(ctx, now.Add(-24*time.Hour), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+	deleted, err = repo.DeleteSentBefore(ctx, now.Add(-24*time.Hour), 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	var remaining int64
+	db.Model(&order.OutboxEvent{}).Count(&remaining)
+	assert.Equal(t, int64(2), remaining, "event FAILED dan SENT terbaru tetap ada")
+}
@@ -0,0 +1,282 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"challenge-order-service/internal/logging"
+
+	"github.com/streadway/amqp"
+)
+
+// ErrBrokerNotConnected dikembalikan saat koneksi RabbitMQ sedang terputus
+var ErrBrokerNotConnected = errors.New("koneksi RabbitMQ sedang terputus")
+
+// ConsumerRunner menjalankan satu consumer di channel khusus sampai delivery
+// berhenti (channel/koneksi tertutup) atau ctx selesai. Queue dan binding
+// dideklarasikan ulang oleh runner setiap kali dijalankan.
+type ConsumerRunner func(ctx context.Context, ch *amqp.Channel) error
+
+// Backoff reconnect default: jeda awal digandakan sampai batas atas
+const (
+	DefaultReconnectBaseBackoff = 500 * time.Millisecond
+	DefaultReconnectMaxBackoff  = 30 * time.Second
+)
+
+// ConnectionManagerConfig mengatur alamat broker, topologi dan backoff reconnect
+type ConnectionManagerConfig struct {
+	URL         string
+	Topology    func(ch *amqp.Channel) error // deklarasi exchange, dijalankan setiap (re)connect
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	Logger      *slog.Logger // nil = slog.Default()
+}
+
+// ConnectionManager menjaga koneksi RabbitMQ: memantau NotifyClose, menyambung
+// ulang dengan backoff, mendeklarasikan ulang topologi, lalu menjalankan hook
+// (mis. mengganti channel PublisherPool). Consumer dijalankan ulang otomatis.
+// Run juga bisa dimulai sebelum koneksi pertama berhasil (mode terdegradasi).
+type ConnectionManager struct {
+	cfg  ConnectionManagerConfig
+	dial func(url string) (*amqp.Connection, error)
+
+	mu          sync.RWMutex
+	conn        *amqp.Connection
+	connected   chan struct{} // ditutup lalu diganti setiap kali koneksi terbuka
+	onReconnect []func()
+	wg          sync.WaitGroup
+}
+
+// NewConnectionManager membuat ConnectionManager; nilai backoff kosong diisi default
+func NewConnectionManager(cfg ConnectionManagerConfig) *ConnectionManager {
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = DefaultReconnectBaseBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = DefaultReconnectMaxBackoff
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return &ConnectionManager{cfg: cfg, dial: amqp.Dial, connected: make(chan struct{})}
+}
+
+// OnReconnect mendaftarkan fungsi yang dipanggil setelah koneksi pulih
+func (m *ConnectionManager) OnReconnect(hook func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onReconnect = append(m.onReconnect, hook)
+}
+
+// Connect menyambung ke broker, mencoba ulang dengan backoff sampai berhasil atau ctx selesai
+func (m *ConnectionManager) Connect(ctx context.Context) error {
+	for attempt := 1; ; attempt++ {
+		err := m.connectOnce()
+		if err == nil {
+			return nil
+		}
+
+		delay := m.backoff(attempt)
+		m.cfg.Logger.WarnContext(ctx, "gagal terhubung ke RabbitMQ",
+			slog.Int("attempt", attempt), slog.Duration("retry_in", delay), logging.Err(err))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("gagal terhubung ke RabbitMQ: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (m *ConnectionManager) connectOnce() error {
+	conn, err := m.dial(m.cfg.URL)
+	if err != nil {
+		return err
+	}
+
+	// Deklarasi ulang exchange: broker yang baru restart bisa saja belum punya topologinya
+	if m.cfg.Topology != nil {
+		ch, err := conn.Channel()
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		err = m.cfg.Topology(ch)
+		ch.Close()
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("gagal mendeklarasikan topologi: %w", err)
+		}
+	}
+
+	m.mu.Lock()
+	m.conn = conn
+	// Bangunkan consumer yang sedang menunggu koneksi
+	close(m.connected)
+	m.connected = make(chan struct{})
+	m.mu.Unlock()
+	return nil
+}
+
+// connectedSignal mengembalikan channel yang ditutup saat koneksi berikutnya terbuka
+func (m *ConnectionManager) connectedSignal() <-chan struct{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.connected
+}
+
+// Run memantau koneksi dan menyambung ulang setiap kali koneksi terputus.
+// Jika Connect belum berhasil (broker belum tersedia saat startup), Run terus
+// mencoba di background lalu menjalankan hook OnReconnect setelah terhubung.
+func (m *ConnectionManager) Run(ctx context.Context) {
+	for {
+		m.mu.RLock()
+		conn := m.conn
+		m.mu.RUnlock()
+
+		if conn == nil {
+			if err := m.Connect(ctx); err != nil {
+				return
+			}
+			m.cfg.Logger.InfoContext(ctx, "koneksi RabbitMQ terhubung")
+			m.runHooks()
+			continue
+		}
+
+		// NotifyClose langsung ditutup jika koneksi sudah terputus sebelum didaftarkan
+		closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		select {
+		case <-ctx.Done():
+			return
+		case amqpErr := <-closed:
+			// Koneksi ditutup oleh Close saat shutdown: jangan menyambung ulang
+			if ctx.Err() != nil {
+				return
+			}
+			// amqpErr nil jika koneksi ditutup tanpa alasan dari broker
+			logger := m.cfg.Logger
+			if amqpErr != nil {
+				logger = logger.With(logging.Err(amqpErr))
+			}
+			logger.WarnContext(ctx, "koneksi RabbitMQ terputus, menyambung ulang")
+		}
+
+		if err := m.Connect(ctx); err != nil {
+			return
+		}
+		m.cfg.Logger.InfoContext(ctx, "koneksi RabbitMQ pulih")
+		m.runHooks()
+	}
+}
+
+// runHooks menjalankan hook OnReconnect setelah koneksi (kembali) terbuka
+func (m *ConnectionManager) runHooks() {
+	m.mu.RLock()
+	hooks := append([]func(){}, m.onReconnect...)
+	m.mu.RUnlock()
+	for _, hook := range hooks {
+		hook()
+	}
+}
+
+// Channel membuka channel baru di koneksi saat ini
+func (m *ConnectionManager) Channel() (*amqp.Channel, error) {
+	m.mu.RLock()
+	conn := m.conn
+	m.mu.RUnlock()
+
+	if conn == nil || conn.IsClosed() {
+		return nil, ErrBrokerNotConnected
+	}
+	return conn.Channel()
+}
+
+// IsConnected bernilai true jika koneksi ke broker sedang terbuka
+func (m *ConnectionManager) IsConnected() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.conn != nil && !m.conn.IsClosed()
+}
+
+// StartConsumer menjalankan runner di goroutine dengan channel khusus dan
+// menjalankannya ulang (dengan backoff) setiap kali berhenti, sampai ctx selesai.
+// Consumer yang menunggu broker langsung dijalankan begitu koneksi terbuka.
+func (m *ConnectionManager) StartConsumer(ctx context.Context, name string, run ConsumerRunner) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+
+		attempt := 0
+		for ctx.Err() == nil {
+			// Diambil sebelum Channel agar koneksi yang terbuka sesudahnya tidak terlewat
+			connected := m.connectedSignal()
+			ch, err := m.Channel()
+			if err == nil {
+				started := time.Now()
+				err = run(ctx, ch)
+				ch.Close()
+				if ctx.Err() != nil {
+					return
+				}
+				// Consumer sempat berjalan lama: anggap gangguan baru, backoff dari awal
+				if time.Since(started) > m.cfg.MaxBackoff {
+					attempt = 0
+				}
+			}
+
+			attempt++
+			delay := m.backoff(attempt)
+			m.cfg.Logger.WarnContext(ctx, "consumer berhenti, dijalankan ulang",
+				slog.String("consumer", name), slog.Duration("retry_in", delay), logging.Err(err))
+			select {
+			case <-ctx.Done():
+				return
+			case <-connected:
+			case <-time.After(delay):
+			}
+		}
+	}()
+}
+
+// WaitConsumers menunggu semua goroutine consumer selesai (setelah ctx consumer
+// dibatalkan), atau sampai ctx selesai
+func (m *ConnectionManager) WaitConsumers(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close menutup koneksi ke broker
+func (m *ConnectionManager) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.conn == nil || m.conn.IsClosed() {
+		return nil
+	}
+	return m.conn.Close()
+}
+
+// backoff = base·2^(attempt-1), dibatasi MaxBackoff
+func (m *ConnectionManager) backoff(attempt int) time.Duration {
+	delay := m.cfg.BaseBackoff
+	for i := 1; i < attempt && delay < m.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > m.cfg.MaxBackoff {
+		delay = m.cfg.MaxBackoff
+	}
+	return delay
+}
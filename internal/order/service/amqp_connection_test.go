@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionManager_Backoff(t *testing.T) {
+	manager := NewConnectionManager(ConnectionManagerConfig{BaseBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	assert.Equal(t, 1*time.Second, manager.backoff(1))
+	assert.Equal(t, 4*time.Second, manager.backoff(3))
+	assert.Equal(t, 10*time.Second, manager.backoff(10))
+}
+
+func TestConnectionManager_ConnectRetriesUntilContextDone(t *testing.T) {
+	manager := NewConnectionManager(ConnectionManagerConfig{BaseBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond})
+	var attempts int32
+	manager.dial = func(string) (*amqp.Connection, error) {
+		atomic.AddInt32(&attempts, 1)
+		return nil, errors.New("connection refused")
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+
+	err := manager.Connect(timeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Greater(t, atomic.LoadInt32(&attempts), int32(1))
+	assert.False(t, manager.IsConnected())
+}
+
+func TestConnectionManager_RunConnectsInBackground(t *testing.T) {
+	manager := NewConnectionManager(ConnectionManagerConfig{BaseBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond})
+	var attempts int32
+	manager.dial = func(string) (*amqp.Connection, error) {
+		atomic.AddInt32(&attempts, 1)
+		return nil, errors.New("connection refused")
+	}
+	var hooks int32
+	manager.OnReconnect(func() { atomic.AddInt32(&hooks, 1) })
+
+	// Run tanpa Connect yang berhasil (startup terdegradasi): terus mencoba sampai ctx selesai
+	runCtx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		manager.Run(runCtx)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run tidak berhenti setelah ctx selesai")
+	}
+	assert.Greater(t, atomic.LoadInt32(&attempts), int32(1))
+	assert.False(t, manager.IsConnected())
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hooks), "hook hanya dijalankan setelah terhubung")
+}
+
+func TestConnectionManager_ConsumerWaitsForConnectionAndStopsOnCancel(t *testing.T) {
+	manager := NewConnectionManager(ConnectionManagerConfig{BaseBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond})
+	consumerCtx, cancel := context.WithCancel(ctx)
+
+	var runs int32
+	manager.StartConsumer(consumerCtx, "test", func(context.Context, *amqp.Channel) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	})
+
+	// Broker belum tersambung: runner tidak pernah dipanggil, goroutine terus mencoba
+	_, err := manager.Channel()
+	assert.ErrorIs(t, err, ErrBrokerNotConnected)
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	assert.NoError(t, manager.WaitConsumers(ctx))
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&runs))
+}
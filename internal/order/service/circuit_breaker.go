@@ -0,0 +1,110 @@
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitState adalah kondisi circuit breaker
+type CircuitState string
+
+const (
+	// CircuitClosed: request diteruskan seperti biasa
+	CircuitClosed CircuitState = "CLOSED"
+	// CircuitOpen: request langsung ditolak sampai masa tunggu habis
+	CircuitOpen CircuitState = "OPEN"
+	// CircuitHalfOpen: satu request percobaan diizinkan untuk menguji upstream
+	CircuitHalfOpen CircuitState = "HALF_OPEN"
+)
+
+// circuitBreaker membuka sirkuit setelah failureThreshold kegagalan berturut-turut,
+// lalu mengizinkan satu request percobaan setelah openTimeout berlalu.
+type circuitBreaker struct {
+	failureThreshold int
+	openTimeout      time.Duration
+	now              func() time.Time
+
+	mu          sync.Mutex
+	state       CircuitState
+	failures    int
+	openedAt    time.Time
+	probeActive bool
+}
+
+func newCircuitBreaker(failureThreshold int, openTimeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		now:              time.Now,
+		state:            CircuitClosed,
+	}
+}
+
+// Allow mengembalikan false jika request harus langsung ditolak
+func (b *circuitBreaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return false
+		}
+		// Masa tunggu habis: izinkan satu request percobaan
+		b.state = CircuitHalfOpen
+		b.probeActive = true
+		return true
+	case CircuitHalfOpen:
+		if b.probeActive {
+			return false
+		}
+		b.probeActive = true
+		return true
+	default:
+		return true
+	}
+}
+
+// Success menutup sirkuit dan mereset hitungan kegagalan
+func (b *circuitBreaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = CircuitClosed
+	b.failures = 0
+	b.probeActive = false
+}
+
+// Failure mencatat kegagalan; sirkuit dibuka jika ambang terlampaui
+// atau request percobaan (half-open) gagal.
+func (b *circuitBreaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.failureThreshold {
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+		b.probeActive = false
+	}
+}
+
+// Abort dipanggil jika request selesai tanpa hasil yang bisa dinilai
+// (mis. dibatalkan oleh klien) agar slot percobaan half-open tidak tertahan.
+func (b *circuitBreaker) Abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probeActive = false
+}
+
+// State mengembalikan kondisi sirkuit saat ini
+func (b *circuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && b.now().Sub(b.openedAt) >= b.openTimeout {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
@@ -0,0 +1,364 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/metrics"
+	"challenge-order-service/internal/tracing"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Header yang ditambahkan consumer saat pesan dikirim ke retry queue / DLQ
+const (
+	RetryCountHeader         = "x-retry-count"
+	LastErrorHeader          = "x-last-error"
+	OriginalExchangeHeader   = "x-original-exchange"
+	OriginalRoutingKeyHeader = "x-original-routing-key"
+)
+
+// DeadLetterExchange adalah exchange (direct) tempat semua DLQ terikat,
+// dengan routing key = nama queue asal
+const DeadLetterExchange = "orders.dlx"
+
+// ErrDeliveriesClosed dikembalikan consumer saat channel delivery ditutup broker
+var ErrDeliveriesClosed = errors.New("channel delivery ditutup")
+
+// RetryQueueName adalah queue penunda (TTL) untuk pesan yang akan dicoba ulang
+func RetryQueueName(queue string) string {
+	return queue + ".retry"
+}
+
+// DeadLetterQueueName adalah queue penampung pesan yang gagal permanen
+func DeadLetterQueueName(queue string) string {
+	return queue + ".dlq"
+}
+
+// permanentError menandai error yang tidak akan berhasil walau dicoba ulang
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent membungkus err agar pesan langsung dikirim ke DLQ tanpa retry
+// (mis. payload tidak valid)
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent bernilai true jika err (atau error yang dibungkusnya) dibuat dengan Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// MessageHandler memproses satu pesan. nil = ack; error = retry atau DLQ.
+type MessageHandler func(ctx context.Context, d amqp.Delivery) error
+
+// ConsumerConfig mengatur queue, binding dan kebijakan retry sebuah consumer
+type ConsumerConfig struct {
+	Queue       string
+	Exchange    string
+	RoutingKeys []string
+	MaxRetries  int           // jumlah retry sebelum pesan dikirim ke DLQ
+	RetryDelay  time.Duration // jeda sebelum pesan dikirim ulang (TTL retry queue)
+	Prefetch    int           // jumlah pesan belum di-ack maksimal per consumer
+	Logger      *slog.Logger  // nil = slog.Default()
+
+	// Exclusive membuat queue milik satu instance (exclusive + auto-delete,
+	// dihapus broker saat koneksi putus) sehingga setiap replika menerima
+	// salinan pesannya sendiri. Queue ini tidak punya retry queue dan DLQ:
+	// pesan yang gagal dibuang.
+	Exclusive bool
+}
+
+// DefaultConsumerConfig adalah konfigurasi yang dipakai untuk nilai yang kosong
+func DefaultConsumerConfig() ConsumerConfig {
+	return ConsumerConfig{
+		MaxRetries: 5,
+		RetryDelay: 5 * time.Second,
+		Prefetch:   10,
+	}
+}
+
+// messagePublisher adalah bagian PublisherImpl yang dipakai untuk retry / DLQ
+type messagePublisher interface {
+	PublishMessage(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error
+}
+
+// Consumer membaca queue dengan manual ack. Pesan yang gagal dikirim ke
+// '<queue>.retry' (kembali ke queue setelah RetryDelay) sampai MaxRetries,
+// lalu ke '<queue>.dlq' lewat DeadLetterExchange (kecuali queue Exclusive).
+type Consumer struct {
+	cfg    ConsumerConfig
+	handle MessageHandler
+}
+
+// NewConsumer membuat Consumer; nilai kosong di cfg diisi default
+func NewConsumer(cfg ConsumerConfig, handle MessageHandler) *Consumer {
+	def := DefaultConsumerConfig()
+	// MaxRetries negatif = tanpa retry, pesan gagal langsung ke DLQ
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = def.MaxRetries
+	} else if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = def.RetryDelay
+	}
+	if cfg.Prefetch <= 0 {
+		cfg.Prefetch = def.Prefetch
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return &Consumer{cfg: cfg, handle: handle}
+}
+
+// Queue mengembalikan nama queue utama consumer
+func (c *Consumer) Queue() string {
+	return c.cfg.Queue
+}
+
+// Run mendeklarasikan topologi lalu memproses pesan sampai channel ditutup
+// atau ctx selesai. Sesuai dengan ConsumerRunner.
+func (c *Consumer) Run(ctx context.Context, ch *amqp.Channel) error {
+	if err := c.declare(ch); err != nil {
+		return err
+	}
+	if err := ch.Qos(c.cfg.Prefetch, 0, false); err != nil {
+		return fmt.Errorf("gagal mengatur prefetch '%s': %w", c.cfg.Queue, err)
+	}
+
+	// Salinan ke retry queue / DLQ dikirim dengan confirm agar tidak hilang sebelum pesan asli di-ack
+	publisher, err := NewPublisherImpl(ch, DefaultConfirmTimeout)
+	if err != nil {
+		return err
+	}
+
+	msgs, err := ch.Consume(
+		c.cfg.Queue,     // queue
+		"",              // consumer
+		false,           // auto-ack (manual ack)
+		c.cfg.Exclusive, // exclusive
+		false,           // no-local
+		false,           // no-wait
+		nil,             // args
+	)
+	if err != nil {
+		return fmt.Errorf("gagal mendaftarkan consumer '%s': %w", c.cfg.Queue, err)
+	}
+
+	c.cfg.Logger.InfoContext(ctx, "consumer dimulai", slog.String("queue", c.cfg.Queue))
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case d, ok := <-msgs:
+			if !ok {
+				return ErrDeliveriesClosed
+			}
+			c.process(ctx, publisher, d)
+		}
+	}
+}
+
+// declare membuat queue utama + binding, retry queue dan DLQ
+func (c *Consumer) declare(ch *amqp.Channel) error {
+	queue := c.cfg.Queue
+
+	if c.cfg.Exclusive {
+		if _, err := ch.QueueDeclare(queue, false, true, true, false, nil); err != nil {
+			return fmt.Errorf("gagal mendeklarasikan queue '%s': %w", queue, err)
+		}
+		return c.bind(ch)
+	}
+
+	if err := ch.ExchangeDeclare(DeadLetterExchange, "direct", true, false, false, false, nil); err != nil {
+		return fmt.Errorf("gagal mendeklarasikan '%s': %w", DeadLetterExchange, err)
+	}
+
+	if _, err := ch.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		return fmt.Errorf("gagal mendeklarasikan queue '%s': %w", queue, err)
+	}
+	if err := c.bind(ch); err != nil {
+		return err
+	}
+
+	// Retry queue tidak punya consumer: setelah TTL habis pesan dikembalikan
+	// ke queue utama lewat default exchange
+	_, err := ch.QueueDeclare(RetryQueueName(queue), true, false, false, false, amqp.Table{
+		"x-message-ttl":             c.cfg.RetryDelay.Milliseconds(),
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": queue,
+	})
+	if err != nil {
+		return fmt.Errorf("gagal mendeklarasikan queue '%s': %w", RetryQueueName(queue), err)
+	}
+
+	if _, err := ch.QueueDeclare(DeadLetterQueueName(queue), true, false, false, false, nil); err != nil {
+		return fmt.Errorf("gagal mendeklarasikan queue '%s': %w", DeadLetterQueueName(queue), err)
+	}
+	if err := ch.QueueBind(DeadLetterQueueName(queue), queue, DeadLetterExchange, false, nil); err != nil {
+		return fmt.Errorf("gagal bind queue '%s': %w", DeadLetterQueueName(queue), err)
+	}
+	return nil
+}
+
+// bind mengikat queue utama ke exchange untuk setiap routing key
+func (c *Consumer) bind(ch *amqp.Channel) error {
+	for _, routingKey := range c.cfg.RoutingKeys {
+		if err := ch.QueueBind(c.cfg.Queue, routingKey, c.cfg.Exchange, false, nil); err != nil {
+			return fmt.Errorf("gagal bind queue '%s' ke '%s': %w", c.cfg.Queue, routingKey, err)
+		}
+	}
+	return nil
+}
+
+// process menjalankan handler lalu meng-ack pesan. Pesan yang gagal disalin
+// ke retry queue atau DLQ terlebih dulu; jika penyalinan gagal, pesan
+// dikembalikan ke queue (nack + requeue) agar tidak hilang.
+func (c *Consumer) process(ctx context.Context, publisher messagePublisher, d amqp.Delivery) {
+	// Pesan dari retry queue / replay DLQ datang lewat default exchange dengan
+	// routing key = nama queue; handler tetap melihat routing key aslinya
+	if routingKey, ok := d.Headers[OriginalRoutingKeyHeader].(string); ok {
+		d.RoutingKey = routingKey
+		if exchange, ok := d.Headers[OriginalExchangeHeader].(string); ok {
+			d.Exchange = exchange
+		}
+	}
+
+	queue := c.cfg.Queue
+	if !d.Timestamp.IsZero() {
+		metrics.ConsumerLag.WithLabelValues(queue).Observe(time.Since(d.Timestamp).Seconds())
+	}
+
+	// Request ID dari request HTTP asal (jika publisher menyertakannya)
+	if requestID, ok := d.Headers[logging.RequestIDHeader].(string); ok && requestID != "" {
+		ctx = logging.WithRequestID(ctx, requestID)
+	}
+
+	// Lanjutkan trace dari publisher (header traceparent). Span ini juga menjadi
+	// parent untuk publish ke retry queue / DLQ di bawah.
+	ctx, span := tracing.Tracer().Start(tracing.ExtractAMQP(ctx, d.Headers), queue+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.operation.type", "process"),
+			attribute.String("messaging.destination.name", queue),
+			attribute.String("messaging.rabbitmq.destination.routing_key", d.RoutingKey),
+			attribute.String("messaging.message.id", d.MessageId),
+			attribute.Int("messaging.rabbitmq.retry_count", retryCount(d.Headers)),
+		))
+	defer span.End()
+	logger := c.cfg.Logger.With(
+		slog.String("queue", queue),
+		slog.String("routing_key", d.RoutingKey),
+		slog.String("message_id", d.MessageId),
+	)
+
+	start := time.Now()
+	err := c.handle(ctx, d)
+	metrics.ConsumerProcessingDuration.WithLabelValues(queue).Observe(time.Since(start).Seconds())
+	tracing.SetError(span, err)
+
+	if err == nil {
+		d.Ack(false)
+		metrics.ConsumedMessages.WithLabelValues(queue, "ack").Inc()
+		return
+	}
+	if ctx.Err() != nil {
+		// Service sedang berhenti: kembalikan pesan untuk diproses instance lain
+		d.Nack(false, true)
+		metrics.ConsumedMessages.WithLabelValues(queue, "requeue").Inc()
+		return
+	}
+
+	if c.cfg.Exclusive {
+		// Queue per instance tidak punya retry queue / DLQ
+		logger.WarnContext(ctx, "pesan gagal diproses, dibuang", logging.Err(err))
+		d.Nack(false, false)
+		metrics.ConsumedMessages.WithLabelValues(queue, "discarded").Inc()
+		return
+	}
+
+	retries := retryCount(d.Headers)
+	if !IsPermanent(err) && retries < c.cfg.MaxRetries {
+		msg := c.failedCopy(d, retries+1, err)
+		if pubErr := publisher.PublishMessage(ctx, "", RetryQueueName(c.cfg.Queue), msg); pubErr != nil {
+			logger.WarnContext(ctx, "gagal mengirim pesan ke retry queue", logging.Err(pubErr))
+			d.Nack(false, true)
+			metrics.ConsumedMessages.WithLabelValues(queue, "requeue").Inc()
+			return
+		}
+		logger.WarnContext(ctx, "pesan gagal diproses, dicoba ulang",
+			slog.Int("attempt", retries+1), slog.Int("max_retries", c.cfg.MaxRetries),
+			slog.Duration("retry_delay", c.cfg.RetryDelay), logging.Err(err))
+		d.Ack(false)
+		metrics.ConsumedMessages.WithLabelValues(queue, "retry").Inc()
+		return
+	}
+
+	msg := c.failedCopy(d, retries, err)
+	if pubErr := publisher.PublishMessage(ctx, DeadLetterExchange, c.cfg.Queue, msg); pubErr != nil {
+		logger.WarnContext(ctx, "gagal mengirim pesan ke DLQ", logging.Err(pubErr))
+		d.Nack(false, true)
+		metrics.ConsumedMessages.WithLabelValues(queue, "requeue").Inc()
+		return
+	}
+	logger.ErrorContext(ctx, "pesan dipindahkan ke DLQ",
+		slog.String("dead_letter_queue", DeadLetterQueueName(c.cfg.Queue)), slog.Int("retries", retries), logging.Err(err))
+	d.Ack(false)
+	metrics.ConsumedMessages.WithLabelValues(queue, "dead_letter").Inc()
+}
+
+// failedCopy menyalin pesan beserta header retry. Exchange & routing key asal
+// hanya dicatat pada kegagalan pertama (pesan dari retry queue datang lewat default exchange).
+func (c *Consumer) failedCopy(d amqp.Delivery, retries int, cause error) amqp.Publishing {
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		headers[k] = v
+	}
+	headers[RetryCountHeader] = int32(retries)
+	headers[LastErrorHeader] = cause.Error()
+	if _, ok := headers[OriginalExchangeHeader]; !ok {
+		headers[OriginalExchangeHeader] = d.Exchange
+		headers[OriginalRoutingKeyHeader] = d.RoutingKey
+	}
+
+	return amqp.Publishing{
+		Headers:       headers,
+		ContentType:   d.ContentType,
+		CorrelationId: d.CorrelationId,
+		MessageId:     d.MessageId,
+		Timestamp:     d.Timestamp,
+		Type:          d.Type,
+		Body:          d.Body,
+	}
+}
+
+// retryCount membaca header x-retry-count (0 jika belum pernah dicoba ulang)
+func retryCount(headers amqp.Table) int {
+	switch v := headers[RetryCountHeader].(type) {
+	case int:
+		return v
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"challenge-order-service/internal/logging"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAcknowledger mencatat ack/nack yang dikirim consumer ke broker
+type fakeAcknowledger struct {
+	acked   bool
+	nacked  bool
+	requeue bool
+}
+
+func (f *fakeAcknowledger) Ack(tag uint64, multiple bool) error {
+	f.acked = true
+	return nil
+}
+
+func (f *fakeAcknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	f.nacked = true
+	f.requeue = requeue
+	return nil
+}
+
+func (f *fakeAcknowledger) Reject(tag uint64, requeue bool) error {
+	return f.Nack(tag, false, requeue)
+}
+
+// recordingPublisher mencatat salinan pesan yang dikirim ke retry queue / DLQ
+type recordingPublisher struct {
+	err       error
+	exchange  string
+	key       string
+	published []amqp.Publishing
+}
+
+func (r *recordingPublisher) PublishMessage(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.exchange, r.key = exchange, routingKey
+	r.published = append(r.published, msg)
+	return nil
+}
+
+func newDelivery(headers amqp.Table) (amqp.Delivery, *fakeAcknowledger) {
+	acker := &fakeAcknowledger{}
+	return amqp.Delivery{
+		Acknowledger: acker,
+		Headers:      headers,
+		Exchange:     "orders_exchange",
+		RoutingKey:   "order.created",
+		MessageId:    "msg-1",
+		Body:         []byte(`{}`),
+	}, acker
+}
+
+func newTestConsumer(handle MessageHandler) *Consumer {
+	return NewConsumer(ConsumerConfig{Queue: "q.orders.log", MaxRetries: 2, RetryDelay: time.Second}, handle)
+}
+
+func TestConsumer_AcksOnSuccess(t *testing.T) {
+	consumer := newTestConsumer(func(ctx context.Context, d amqp.Delivery) error { return nil })
+	publisher := &recordingPublisher{}
+	d, acker := newDelivery(nil)
+
+	consumer.process(ctx, publisher, d)
+
+	assert.True(t, acker.acked)
+	assert.Empty(t, publisher.published)
+}
+
+func TestConsumer_FailureGoesToRetryQueue(t *testing.T) {
+	consumer := newTestConsumer(func(ctx context.Context, d amqp.Delivery) error { return errors.New("db down") })
+	publisher := &recordingPublisher{}
+	d, acker := newDelivery(nil)
+
+	consumer.process(ctx, publisher, d)
+
+	assert.True(t, acker.acked)
+	assert.Equal(t, "", publisher.exchange)
+	assert.Equal(t, "q.orders.log.retry", publisher.key)
+	headers := publisher.published[0].Headers
+	assert.Equal(t, int32(1), headers[RetryCountHeader])
+	assert.Equal(t, "db down", headers[LastErrorHeader])
+	assert.Equal(t, "order.created", headers[OriginalRoutingKeyHeader])
+	assert.Equal(t, "msg-1", publisher.published[0].MessageId)
+}
+
+func TestConsumer_RetriedMessageKeepsOriginalRoutingKey(t *testing.T) {
+	var seen string
+	consumer := newTestConsumer(func(ctx context.Context, d amqp.Delivery) error {
+		seen = d.RoutingKey
+		return nil
+	})
+	// Pesan dari retry queue datang lewat default exchange dengan routing key = nama queue
+	d, _ := newDelivery(amqp.Table{RetryCountHeader: int32(1), OriginalRoutingKeyHeader: "order.created"})
+	d.Exchange, d.RoutingKey = "", "q.orders.log"
+
+	consumer.process(ctx, &recordingPublisher{}, d)
+
+	assert.Equal(t, "order.created", seen)
+}
+
+func TestConsumer_ExhaustedRetriesGoToDLQ(t *testing.T) {
+	consumer := newTestConsumer(func(ctx context.Context, d amqp.Delivery) error { return errors.New("db down") })
+	publisher := &recordingPublisher{}
+	d, acker := newDelivery(amqp.Table{RetryCountHeader: int32(2)})
+
+	consumer.process(ctx, publisher, d)
+
+	assert.True(t, acker.acked)
+	assert.Equal(t, DeadLetterExchange, publisher.exchange)
+	assert.Equal(t, "q.orders.log", publisher.key)
+	assert.Equal(t, int32(2), publisher.published[0].Headers[RetryCountHeader])
+}
+
+func TestConsumer_PermanentErrorSkipsRetry(t *testing.T) {
+	consumer := newTestConsumer(func(ctx context.Context, d amqp.Delivery) error {
+		return Permanent(ErrInvalidStockEvent)
+	})
+	publisher := &recordingPublisher{}
+	d, _ := newDelivery(nil)
+
+	consumer.process(ctx, publisher, d)
+
+	assert.Equal(t, DeadLetterExchange, publisher.exchange)
+}
+
+func TestConsumer_ExclusiveQueueDiscardsFailedMessage(t *testing.T) {
+	consumer := NewConsumer(ConsumerConfig{Queue: "q.orders.product_events.host-1", Exclusive: true},
+		func(ctx context.Context, d amqp.Delivery) error { return errors.New("gagal") })
+	publisher := &recordingPublisher{}
+	d, acker := newDelivery(nil)
+
+	consumer.process(ctx, publisher, d)
+
+	// Tidak ada retry queue / DLQ untuk queue per instance
+	assert.Empty(t, publisher.published)
+	assert.True(t, acker.nacked)
+	assert.False(t, acker.requeue)
+}
+
+func TestConsumer_RequeuesWhenRepublishFails(t *testing.T) {
+	consumer := newTestConsumer(func(ctx context.Context, d amqp.Delivery) error { return errors.New("db down") })
+	d, acker := newDelivery(nil)
+
+	consumer.process(ctx, &recordingPublisher{err: ErrPublishTimeout}, d)
+
+	assert.False(t, acker.acked)
+	assert.True(t, acker.nacked)
+	assert.True(t, acker.requeue)
+}
+
+func TestConsumer_RequeuesOnShutdown(t *testing.T) {
+	cancelled, cancel := context.WithCancel(ctx)
+	consumer := newTestConsumer(func(ctx context.Context, d amqp.Delivery) error {
+		cancel()
+		return ctx.Err()
+	})
+	publisher := &recordingPublisher{}
+	d, acker := newDelivery(nil)
+
+	consumer.process(cancelled, publisher, d)
+
+	assert.True(t, acker.nacked)
+	assert.True(t, acker.requeue)
+	assert.Empty(t, publisher.published)
+}
+
+func TestPermanent_Unwraps(t *testing.T) {
+	err := Permanent(ErrInvalidProductEvent)
+	assert.True(t, IsPermanent(err))
+	assert.ErrorIs(t, err, ErrInvalidProductEvent)
+	assert.False(t, IsPermanent(ErrInvalidProductEvent))
+	assert.Nil(t, Permanent(nil))
+}
+
+func TestConsumer_RestoresRequestIDFromHeader(t *testing.T) {
+	var seen string
+	consumer := newTestConsumer(func(ctx context.Context, d amqp.Delivery) error {
+		seen = logging.RequestID(ctx)
+		return nil
+	})
+	d, _ := newDelivery(amqp.Table{logging.RequestIDHeader: "req-42"})
+
+	consumer.process(ctx, &recordingPublisher{}, d)
+
+	assert.Equal(t, "req-42", seen)
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// ErrUnknownDeadLetterQueue dikembalikan untuk queue yang tidak dikelola DeadLetterManager
+var ErrUnknownDeadLetterQueue = errors.New("queue tidak memiliki dead-letter queue")
+
+// DefaultReplayLimit adalah jumlah pesan yang di-replay jika limit tidak diatur
+const DefaultReplayLimit = 100
+
+// DefaultPeekLimit adalah jumlah pesan yang ditampilkan Peek jika limit tidak diatur
+const DefaultPeekLimit = 10
+
+// DeadLetterQueueStats adalah isi satu DLQ
+type DeadLetterQueueStats struct {
+	Queue           string `json:"queue"`
+	DeadLetterQueue string `json:"deadLetterQueue"`
+	Messages        int    `json:"messages"`
+}
+
+// DeadLetterMessage adalah satu pesan DLQ yang ditampilkan tanpa mengeluarkannya dari queue
+type DeadLetterMessage struct {
+	Exchange    string     `json:"exchange"`
+	RoutingKey  string     `json:"routingKey"`
+	RetryCount  int        `json:"retryCount"`
+	LastError   string     `json:"lastError,omitempty"`
+	Headers     amqp.Table `json:"headers,omitempty"`
+	ContentType string     `json:"contentType,omitempty"`
+	MessageID   string     `json:"messageId,omitempty"`
+	Timestamp   time.Time  `json:"timestamp,omitempty"`
+	Body        string     `json:"body"`
+}
+
+// DeadLetterService dipakai endpoint admin untuk memeriksa dan me-replay DLQ
+type DeadLetterService interface {
+	Stats(ctx context.Context) ([]DeadLetterQueueStats, error)
+	Peek(ctx context.Context, queue string, limit int) ([]DeadLetterMessage, error)
+	Replay(ctx context.Context, queue string, limit int) (int, error)
+}
+
+// DeadLetterManager mengimplementasikan DeadLetterService di atas broker
+type DeadLetterManager struct {
+	open   func() (*amqp.Channel, error)
+	queues []string
+}
+
+// NewDeadLetterManager membuat manager untuk DLQ milik queues (nama queue utama consumer)
+func NewDeadLetterManager(open func() (*amqp.Channel, error), queues ...string) *DeadLetterManager {
+	return &DeadLetterManager{open: open, queues: queues}
+}
+
+func (m *DeadLetterManager) known(queue string) bool {
+	for _, q := range m.queues {
+		if q == queue {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats mengembalikan jumlah pesan di setiap DLQ
+func (m *DeadLetterManager) Stats(ctx context.Context) ([]DeadLetterQueueStats, error) {
+	ch, err := m.open()
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	stats := make([]DeadLetterQueueStats, 0, len(m.queues))
+	for _, queue := range m.queues {
+		q, err := ch.QueueInspect(DeadLetterQueueName(queue))
+		if err != nil {
+			return nil, fmt.Errorf("gagal memeriksa queue '%s': %w", DeadLetterQueueName(queue), err)
+		}
+		stats = append(stats, DeadLetterQueueStats{
+			Queue:           queue,
+			DeadLetterQueue: q.Name,
+			Messages:        q.Messages,
+		})
+	}
+	return stats, nil
+}
+
+// Peek menampilkan maksimal limit pesan terdepan di DLQ milik queue. Pesan
+// dibaca tanpa ack lalu dikembalikan (Nack requeue) sehingga isi DLQ tidak berubah.
+func (m *DeadLetterManager) Peek(ctx context.Context, queue string, limit int) ([]DeadLetterMessage, error) {
+	if !m.known(queue) {
+		return nil, ErrUnknownDeadLetterQueue
+	}
+	if limit <= 0 {
+		limit = DefaultPeekLimit
+	}
+
+	ch, err := m.open()
+	if err != nil {
+		return nil, err
+	}
+	defer ch.Close()
+
+	dlq := DeadLetterQueueName(queue)
+	return peekMessages(ctx, func() (amqp.Delivery, bool, error) { return ch.Get(dlq, false) }, limit)
+}
+
+// peekMessages membaca sampai limit pesan lewat get. Semua pesan baru di-requeue
+// setelah selesai dibaca; requeue satu per satu akan membuat Get berikutnya
+// mengembalikan pesan yang sama.
+func peekMessages(ctx context.Context, get func() (amqp.Delivery, bool, error), limit int) ([]DeadLetterMessage, error) {
+	var deliveries []amqp.Delivery
+	defer func() {
+		for _, d := range deliveries {
+			d.Nack(false, true)
+		}
+	}()
+
+	messages := make([]DeadLetterMessage, 0, limit)
+	for len(messages) < limit {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		d, ok, err := get()
+		if err != nil {
+			return nil, fmt.Errorf("gagal membaca dead-letter queue: %w", err)
+		}
+		if !ok {
+			break // DLQ sudah habis dibaca
+		}
+		deliveries = append(deliveries, d)
+
+		lastError, _ := d.Headers[LastErrorHeader].(string)
+		messages = append(messages, DeadLetterMessage{
+			Exchange:    d.Exchange,
+			RoutingKey:  d.RoutingKey,
+			RetryCount:  retryCount(d.Headers),
+			LastError:   lastError,
+			Headers:     d.Headers,
+			ContentType: d.ContentType,
+			MessageID:   d.MessageId,
+			Timestamp:   d.Timestamp,
+			Body:        string(d.Body),
+		})
+	}
+	return messages, nil
+}
+
+// Replay memindahkan maksimal limit pesan dari DLQ kembali ke queue utamanya.
+// Pesan dikirim lewat default exchange, sehingga hanya consumer queue tersebut
+// yang menerimanya, dan hitungan retry dimulai dari nol.
+func (m *DeadLetterManager) Replay(ctx context.Context, queue string, limit int) (int, error) {
+	if !m.known(queue) {
+		return 0, ErrUnknownDeadLetterQueue
+	}
+	if limit <= 0 {
+		limit = DefaultReplayLimit
+	}
+
+	ch, err := m.open()
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+
+	publisher, err := NewPublisherImpl(ch, DefaultConfirmTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for replayed < limit {
+		if err := ctx.Err(); err != nil {
+			return replayed, err
+		}
+
+		d, ok, err := ch.Get(DeadLetterQueueName(queue), false)
+		if err != nil {
+			return replayed, fmt.Errorf("gagal membaca queue '%s': %w", DeadLetterQueueName(queue), err)
+		}
+		if !ok {
+			break // DLQ sudah kosong
+		}
+
+		// Pesan asli baru di-ack setelah salinannya dikonfirmasi broker
+		if err := publisher.PublishMessage(ctx, "", queue, replayCopy(d)); err != nil {
+			d.Nack(false, true)
+			return replayed, err
+		}
+		if err := d.Ack(false); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// replayCopy menyalin pesan DLQ tanpa header retry; exchange & routing key asal dipertahankan
+func replayCopy(d amqp.Delivery) amqp.Publishing {
+	headers := amqp.Table{}
+	for k, v := range d.Headers {
+		if k == RetryCountHeader || k == LastErrorHeader {
+			continue
+		}
+		headers[k] = v
+	}
+
+	return amqp.Publishing{
+		Headers:       headers,
+		ContentType:   d.ContentType,
+		CorrelationId: d.CorrelationId,
+		MessageId:     d.MessageId,
+		Timestamp:     d.Timestamp,
+		Type:          d.Type,
+		Body:          d.Body,
+	}
+}
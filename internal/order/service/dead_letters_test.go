@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeekMessages_RequeuesAfterReading(t *testing.T) {
+	first, firstAck := newDelivery(amqp.Table{RetryCountHeader: int32(5), LastErrorHeader: "db down"})
+	first.Body = []byte(`{"orderId":"1"}`)
+	second, secondAck := newDelivery(nil)
+	queue := []amqp.Delivery{first, second}
+
+	get := func() (amqp.Delivery, bool, error) {
+		// Pesan yang sudah di-requeue akan kembali ke depan queue
+		if firstAck.nacked || len(queue) == 0 {
+			return amqp.Delivery{}, false, nil
+		}
+		d := queue[0]
+		queue = queue[1:]
+		return d, true, nil
+	}
+
+	messages, err := peekMessages(context.Background(), get, 10)
+
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, "order.created", messages[0].RoutingKey)
+		assert.Equal(t, 5, messages[0].RetryCount)
+		assert.Equal(t, "db down", messages[0].LastError)
+		assert.Equal(t, `{"orderId":"1"}`, messages[0].Body)
+	}
+	// Semua pesan dikembalikan ke DLQ, tidak ada yang di-ack
+	for _, acker := range []*fakeAcknowledger{firstAck, secondAck} {
+		assert.True(t, acker.nacked)
+		assert.True(t, acker.requeue)
+		assert.False(t, acker.acked)
+	}
+}
+
+func TestPeekMessages_LimitAndError(t *testing.T) {
+	d, acker := newDelivery(nil)
+	calls := 0
+	get := func() (amqp.Delivery, bool, error) {
+		calls++
+		return d, true, nil
+	}
+
+	messages, err := peekMessages(context.Background(), get, 1)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, 1, calls, "tidak membaca melebihi limit")
+	assert.True(t, acker.requeue)
+
+	failing := func() (amqp.Delivery, bool, error) { return amqp.Delivery{}, false, errors.New("channel closed") }
+	_, err = peekMessages(context.Background(), failing, 5)
+	assert.ErrorContains(t, err, "channel closed")
+}
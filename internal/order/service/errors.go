@@ -0,0 +1,24 @@
+package service
+
+import "errors"
+
+// Error domain yang dipetakan handler ke status HTTP. Error yang dikembalikan
+// service dibungkus dengan fmt.Errorf("%w: ...") sehingga diperiksa dengan errors.Is.
+var (
+	// ErrValidation: request valid secara format tetapi melanggar aturan bisnis
+	ErrValidation = errors.New("validasi gagal")
+	// ErrInsufficientStock: stok produk lebih kecil dari jumlah pesanan
+	ErrInsufficientStock = errors.New("stok produk tidak mencukupi")
+	// ErrProductNotFound: product-service menjawab 404 untuk produk yang dipesan
+	ErrProductNotFound = errors.New("produk tidak ditemukan")
+	// ErrUpstreamUnavailable: layanan lain (mis. product-service) tidak bisa dihubungi
+	ErrUpstreamUnavailable = errors.New("layanan upstream tidak tersedia")
+)
+
+// upstreamError adalah ErrUpstreamUnavailable untuk satu layanan tertentu
+type upstreamError struct {
+	service string
+}
+
+func (e *upstreamError) Error() string { return e.service + " tidak tersedia" }
+func (e *upstreamError) Unwrap() error { return ErrUpstreamUnavailable }
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/order"
+	"challenge-order-service/internal/order/repository"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// IdempotencyStore menyimpan respons pertama untuk setiap Idempotency-Key
+type IdempotencyStore interface {
+	// Acquire mengunci key untuk request ini. Jika key sudah dipakai,
+	// record yang ada dikembalikan dengan acquired = false.
+	Acquire(ctx context.Context, key, fingerprint string) (existing *order.IdempotencyRecord, acquired bool, err error)
+	// Get mengembalikan nil jika key tidak ada atau sudah kedaluwarsa
+	Get(ctx context.Context, key string) (*order.IdempotencyRecord, error)
+	// Complete menyimpan respons final untuk key
+	Complete(ctx context.Context, key, fingerprint string, statusCode int, body []byte) error
+	// Release melepas kunci agar request berikutnya dengan key yang sama diproses ulang
+	Release(ctx context.Context, key string) error
+}
+
+// idempotencyStore memakai Redis sebagai penyimpanan utama (dengan TTL) dan
+// tabel 'idempotency_keys' sebagai fallback ketika Redis tidak bisa dihubungi.
+// Key yang dikunci di DB juga diselesaikan/dilepas di DB walaupun Redis sudah
+// pulih, Acquire lewat Redis tetap memeriksa DB, dan Get membaca DB saat key
+// tidak ada di Redis.
+type idempotencyStore struct {
+	rdb     *redis.Client
+	repo    repository.IdempotencyRepository
+	ttl     time.Duration // masa simpan respons yang sudah selesai
+	lockTTL time.Duration // masa kunci IN_PROGRESS, agar key tidak terkunci selamanya jika proses mati
+	now     func() time.Time
+
+	dbKeys sync.Map // key yang dikunci proses ini lewat fallback DB
+}
+
+func NewIdempotencyStore(rdb *redis.Client, repo repository.IdempotencyRepository) IdempotencyStore {
+	return &idempotencyStore{
+		rdb:     rdb,
+		repo:    repo,
+		ttl:     24 * time.Hour,
+		lockTTL: 30 * time.Second,
+		now:     time.Now,
+	}
+}
+
+func idempotencyCacheKey(key string) string {
+	return fmt.Sprintf("idempotency:%s", key)
+}
+
+func (s *idempotencyStore) Acquire(ctx context.Context, key, fingerprint string) (*order.IdempotencyRecord, bool, error) {
+	record := &order.IdempotencyRecord{
+		Key:         key,
+		Fingerprint: fingerprint,
+		State:       order.IdempotencyInProgress,
+		ExpiresAt:   s.now().Add(s.lockTTL),
+		CreatedAt:   s.now(),
+	}
+	data, _ := json.Marshal(record)
+
+	acquired, err := s.rdb.SetNX(ctx, idempotencyCacheKey(key), data, s.lockTTL).Result()
+	if err == nil {
+		if acquired {
+			return s.checkDB(ctx, key)
+		}
+		existing, err := s.getRedis(ctx, key)
+		if err == nil {
+			if existing == nil {
+				// Key kedaluwarsa di antara SETNX dan GET, coba kunci sekali lagi
+				acquired, err = s.rdb.SetNX(ctx, idempotencyCacheKey(key), data, s.lockTTL).Result()
+				if err == nil {
+					return nil, acquired, nil
+				}
+			} else {
+				return existing, false, nil
+			}
+		}
+	}
+
+	slog.WarnContext(ctx, "Redis tidak tersedia untuk Idempotency-Key, fallback ke DB", logging.Err(err))
+	return s.acquireDB(ctx, record)
+}
+
+// checkDB dipanggil setelah SETNX berhasil: key mungkin sudah dikunci atau
+// diselesaikan di DB selama Redis tidak tersedia (salinan ke Redis bersifat
+// best-effort). Jika ada record yang masih berlaku, kunci Redis dilepas dan
+// record tersebut dikembalikan agar order tidak dibuat dua kali.
+func (s *idempotencyStore) checkDB(ctx context.Context, key string) (*order.IdempotencyRecord, bool, error) {
+	if s.repo == nil {
+		return nil, true, nil
+	}
+	existing, err := s.repo.Find(ctx, key)
+	if err != nil {
+		s.rdb.Del(ctx, idempotencyCacheKey(key))
+		return nil, false, err
+	}
+	if existing != nil && !existing.IsExpired(s.now()) {
+		s.rdb.Del(ctx, idempotencyCacheKey(key))
+		return existing, false, nil
+	}
+	return nil, true, nil
+}
+
+func (s *idempotencyStore) acquireDB(ctx context.Context, record *order.IdempotencyRecord) (*order.IdempotencyRecord, bool, error) {
+	inserted, err := s.repo.Insert(ctx, record)
+	if err != nil {
+		return nil, false, err
+	}
+	if inserted {
+		s.dbKeys.Store(record.Key, struct{}{})
+		return nil, true, nil
+	}
+
+	existing, err := s.repo.Find(ctx, record.Key)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil && !existing.IsExpired(s.now()) {
+		return existing, false, nil
+	}
+
+	// Record lama sudah kedaluwarsa: hapus lalu kunci ulang
+	if err := s.repo.Delete(ctx, record.Key); err != nil {
+		return nil, false, err
+	}
+	inserted, err = s.repo.Insert(ctx, record)
+	if inserted {
+		s.dbKeys.Store(record.Key, struct{}{})
+	}
+	return nil, inserted, err
+}
+
+func (s *idempotencyStore) Get(ctx context.Context, key string) (*order.IdempotencyRecord, error) {
+	record, err := s.getRedis(ctx, key)
+	if err == nil && (record != nil || s.repo == nil) {
+		return record, nil
+	}
+
+	// Redis error, atau key tidak ada di Redis karena dikunci/diselesaikan di DB
+	record, err = s.repo.Find(ctx, key)
+	if err != nil || record == nil || record.IsExpired(s.now()) {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (s *idempotencyStore) getRedis(ctx context.Context, key string) (*order.IdempotencyRecord, error) {
+	val, err := s.rdb.Get(ctx, idempotencyCacheKey(key)).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record order.IdempotencyRecord
+	if err := json.Unmarshal(val, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (s *idempotencyStore) Complete(ctx context.Context, key, fingerprint string, statusCode int, body []byte) error {
+	record := &order.IdempotencyRecord{
+		Key:          key,
+		Fingerprint:  fingerprint,
+		State:        order.IdempotencyCompleted,
+		StatusCode:   statusCode,
+		ResponseBody: body,
+		ExpiresAt:    s.now().Add(s.ttl),
+		CreatedAt:    s.now(),
+	}
+	data, _ := json.Marshal(record)
+
+	if _, ok := s.dbKeys.LoadAndDelete(key); ok {
+		if err := s.repo.Upsert(ctx, record); err != nil {
+			return err
+		}
+		// Salinan di Redis (jika sudah pulih) agar Acquire berikutnya melihat respons ini
+		s.rdb.Set(ctx, idempotencyCacheKey(key), data, s.ttl)
+		return nil
+	}
+
+	if err := s.rdb.Set(ctx, idempotencyCacheKey(key), data, s.ttl).Err(); err != nil {
+		slog.WarnContext(ctx, "Redis tidak tersedia untuk Idempotency-Key, fallback ke DB", logging.Err(err))
+		return s.repo.Upsert(ctx, record)
+	}
+	return nil
+}
+
+func (s *idempotencyStore) Release(ctx context.Context, key string) error {
+	if _, ok := s.dbKeys.LoadAndDelete(key); ok {
+		return s.repo.Delete(ctx, key)
+	}
+	if err := s.rdb.Del(ctx, idempotencyCacheKey(key)).Err(); err != nil {
+		return s.repo.Delete(ctx, key)
+	}
+	return nil
+}
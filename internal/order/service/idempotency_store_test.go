@@ -0,0 +1,167 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"challenge-order-service/internal/order"
+	"challenge-order-service/internal/order/repository"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupIdempotencyStoreTest(t *testing.T) (IdempotencyStore, *repository.MockIdempotencyRepository, *miniredis.Miniredis) {
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	mockRepo := new(repository.MockIdempotencyRepository)
+
+	return NewIdempotencyStore(rdb, mockRepo), mockRepo, mr
+}
+
+func TestIdempotencyStore_Redis_AcquireCompleteReplay(t *testing.T) {
+	store, mockRepo, mr := setupIdempotencyStoreTest(t)
+	defer mr.Close()
+	mockRepo.On("Find", mock.Anything, "key-1").Return(nil, nil).Once()
+
+	// 1. Request pertama mendapat kunci
+	existing, acquired, err := store.Acquire(ctx, "key-1", "fp")
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Nil(t, existing)
+
+	// 2. Request kedua melihat record IN_PROGRESS
+	existing, acquired, err = store.Acquire(ctx, "key-1", "fp")
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	assert.Equal(t, order.IdempotencyInProgress, existing.State)
+
+	// 3. Setelah Complete, respons tersimpan dengan TTL
+	assert.NoError(t, store.Complete(ctx, "key-1", "fp", 201, []byte(`{"id":"x"}`)))
+	record, err := store.Get(ctx, "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, order.IdempotencyCompleted, record.State)
+	assert.Equal(t, 201, record.StatusCode)
+	assert.True(t, mr.TTL(idempotencyCacheKey("key-1")) > 0)
+
+	// DB fallback tidak dipakai selama Redis sehat
+	mockRepo.AssertNotCalled(t, "Insert", mock.Anything)
+}
+
+func TestIdempotencyStore_Release(t *testing.T) {
+	store, mockRepo, mr := setupIdempotencyStoreTest(t)
+	defer mr.Close()
+	mockRepo.On("Find", mock.Anything, "key-2").Return(nil, nil).Twice()
+
+	_, _, err := store.Acquire(ctx, "key-2", "fp")
+	assert.NoError(t, err)
+	assert.NoError(t, store.Release(ctx, "key-2"))
+
+	_, acquired, err := store.Acquire(ctx, "key-2", "fp")
+	assert.NoError(t, err)
+	assert.True(t, acquired, "Key yang dilepas harus bisa dikunci ulang")
+}
+
+func TestIdempotencyStore_FallsBackToDBWhenRedisDown(t *testing.T) {
+	store, mockRepo, mr := setupIdempotencyStoreTest(t)
+	mr.Close() // Redis mati
+
+	mockRepo.On("Insert", mock.Anything, mock.MatchedBy(func(r *order.IdempotencyRecord) bool {
+		return r.Key == "key-3" && r.State == order.IdempotencyInProgress
+	})).Return(true, nil).Once()
+	mockRepo.On("Upsert", mock.Anything, mock.MatchedBy(func(r *order.IdempotencyRecord) bool {
+		return r.Key == "key-3" && r.State == order.IdempotencyCompleted && r.StatusCode == 201
+	})).Return(nil).Once()
+
+	_, acquired, err := store.Acquire(ctx, "key-3", "fp")
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.NoError(t, store.Complete(ctx, "key-3", "fp", 201, []byte(`{}`)))
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyStore_CompletesInDBAfterRedisRecovers(t *testing.T) {
+	store, mockRepo, mr := setupIdempotencyStoreTest(t)
+	defer mr.Close()
+	mr.Close() // Redis mati saat key dikunci
+
+	mockRepo.On("Insert", mock.Anything, mock.Anything).Return(true, nil).Once()
+	mockRepo.On("Upsert", mock.Anything, mock.MatchedBy(func(r *order.IdempotencyRecord) bool {
+		return r.Key == "key-5" && r.State == order.IdempotencyCompleted
+	})).Return(nil).Once()
+
+	_, acquired, err := store.Acquire(ctx, "key-5", "fp")
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// Redis pulih sebelum request selesai: respons tetap ditulis ke DB tempat kuncinya
+	assert.NoError(t, mr.Restart())
+	assert.NoError(t, store.Complete(ctx, "key-5", "fp", 201, []byte(`{}`)))
+	assert.True(t, mr.Exists(idempotencyCacheKey("key-5")), "Salinan respons juga ditulis ke Redis")
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyStore_AcquireReturnsRecordCompletedInDB(t *testing.T) {
+	store, mockRepo, mr := setupIdempotencyStoreTest(t)
+	defer mr.Close()
+
+	// Key diselesaikan di DB saat Redis mati; salinan ke Redis gagal
+	dbRecord := &order.IdempotencyRecord{
+		Key: "key-7", Fingerprint: "fp", State: order.IdempotencyCompleted,
+		StatusCode: 201, ResponseBody: []byte(`{}`), ExpiresAt: time.Now().Add(time.Hour),
+	}
+	mockRepo.On("Find", mock.Anything, "key-7").Return(dbRecord, nil).Once()
+
+	existing, acquired, err := store.Acquire(ctx, "key-7", "fp")
+
+	assert.NoError(t, err)
+	assert.False(t, acquired, "SETNX yang berhasil tidak boleh menimpa record di DB")
+	assert.Equal(t, dbRecord, existing)
+	assert.False(t, mr.Exists(idempotencyCacheKey("key-7")), "kunci Redis dilepas")
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyStore_AcquireIgnoresExpiredDBRecord(t *testing.T) {
+	store, mockRepo, mr := setupIdempotencyStoreTest(t)
+	defer mr.Close()
+
+	expired := &order.IdempotencyRecord{Key: "key-8", State: order.IdempotencyCompleted, ExpiresAt: time.Now().Add(-time.Minute)}
+	mockRepo.On("Find", mock.Anything, "key-8").Return(expired, nil).Once()
+
+	_, acquired, err := store.Acquire(ctx, "key-8", "fp")
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestIdempotencyStore_GetFallsBackToDBOnRedisMiss(t *testing.T) {
+	store, mockRepo, mr := setupIdempotencyStoreTest(t)
+	defer mr.Close()
+
+	// Key dikunci lewat DB oleh replika lain saat Redis tidak tersedia
+	dbRecord := &order.IdempotencyRecord{Key: "key-6", State: order.IdempotencyInProgress, ExpiresAt: time.Now().Add(time.Minute)}
+	mockRepo.On("Find", mock.Anything, "key-6").Return(dbRecord, nil).Once()
+
+	record, err := store.Get(ctx, "key-6")
+	assert.NoError(t, err)
+	assert.Equal(t, dbRecord, record)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestIdempotencyStore_DBFallbackError(t *testing.T) {
+	store, mockRepo, mr := setupIdempotencyStoreTest(t)
+	mr.Close()
+
+	mockRepo.On("Insert", mock.Anything, mock.Anything).Return(false, errors.New("db down")).Once()
+
+	_, acquired, err := store.Acquire(ctx, "key-4", "fp")
+	assert.Error(t, err)
+	assert.False(t, acquired)
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/metrics"
+	"challenge-order-service/internal/order"
+	"challenge-order-service/internal/order/repository"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrQueueFull dikembalikan saat buffer BatchWriter penuh (backpressure ke klien)
+var ErrQueueFull = errors.New("antrean penulisan order penuh, coba lagi nanti")
+
+// ErrWriterClosed dikembalikan saat BatchWriter sudah dihentikan
+var ErrWriterClosed = errors.New("penulis order asinkron sudah dihentikan")
+
+// BatchWriterConfig mengatur ukuran buffer, batch dan jumlah worker
+type BatchWriterConfig struct {
+	BufferSize     int           // kapasitas antrean in-process
+	BatchSize      int           // jumlah order maksimal per INSERT multi-baris
+	FlushInterval  time.Duration // batas waktu tunggu sebelum batch yang belum penuh di-flush
+	Workers        int           // jumlah goroutine penulis
+	EnqueueTimeout time.Duration // lama menunggu slot kosong sebelum ErrQueueFull
+	MaxRetries     int           // retry INSERT batch sebelum fallback per order; negatif = tanpa retry
+	RetryBaseDelay time.Duration // jeda retry pertama, berlipat dua setiap percobaan
+	Logger         *slog.Logger  // nil = slog.Default()
+}
+
+// DefaultBatchWriterConfig adalah konfigurasi yang dipakai untuk nilai yang kosong
+func DefaultBatchWriterConfig() BatchWriterConfig {
+	return BatchWriterConfig{
+		BufferSize:     10000,
+		BatchSize:      500,
+		FlushInterval:  50 * time.Millisecond,
+		Workers:        4,
+		EnqueueTimeout: 100 * time.Millisecond,
+		MaxRetries:     3,
+		RetryBaseDelay: 100 * time.Millisecond,
+	}
+}
+
+// pendingWrite adalah satu order beserta event outbox-nya yang menunggu di-flush
+type pendingWrite struct {
+	order *order.Order
+	event *order.OutboxEvent
+}
+
+// BatchWriter menampung order di buffer in-process lalu menuliskannya
+// dalam INSERT multi-baris (order + outbox) lewat OrderRepository.
+// Event 'order.created' tetap dikirim oleh OutboxRelay secara batch.
+//
+// Order yang sudah dibalas 202 bisa hilang dalam dua kasus: proses mati
+// sebelum buffer di-flush (buffer hanya ada di memori), atau order tetap gagal
+// disimpan setelah semua retry. Kasus kedua dicatat di metrik
+// order_service_orders_dropped_total.
+type BatchWriter struct {
+	repo    repository.OrderRepository
+	cfg     BatchWriterConfig
+	queue   chan pendingWrite
+	onFlush func(ctx context.Context, orders []*order.Order)
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewBatchWriter membuat BatchWriter. onFlush (opsional) dipanggil setelah batch tersimpan.
+func NewBatchWriter(repo repository.OrderRepository, cfg BatchWriterConfig, onFlush func(ctx context.Context, orders []*order.Order)) *BatchWriter {
+	def := DefaultBatchWriterConfig()
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = def.BufferSize
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = def.BatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = def.FlushInterval
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = def.Workers
+	}
+	if cfg.EnqueueTimeout < 0 {
+		cfg.EnqueueTimeout = 0
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = def.MaxRetries
+	} else if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBaseDelay <= 0 {
+		cfg.RetryBaseDelay = def.RetryBaseDelay
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+
+	return &BatchWriter{
+		repo:    repo,
+		cfg:     cfg,
+		queue:   make(chan pendingWrite, cfg.BufferSize),
+		onFlush: onFlush,
+	}
+}
+
+// SetOnFlush mengganti callback yang dipanggil setelah batch tersimpan
+func (w *BatchWriter) SetOnFlush(onFlush func(ctx context.Context, orders []*order.Order)) {
+	w.onFlush = onFlush
+}
+
+// Start menjalankan goroutine worker
+func (w *BatchWriter) Start() {
+	for i := 0; i < w.cfg.Workers; i++ {
+		w.wg.Add(1)
+		go w.worker()
+	}
+	w.cfg.Logger.Info("batch writer dimulai",
+		slog.Int("workers", w.cfg.Workers), slog.Int("batch_size", w.cfg.BatchSize),
+		slog.Duration("flush_interval", w.cfg.FlushInterval), slog.Int("buffer_size", w.cfg.BufferSize))
+}
+
+// Enqueue memasukkan order ke buffer. Jika buffer penuh lebih lama dari
+// EnqueueTimeout, ErrQueueFull dikembalikan agar klien bisa mencoba lagi.
+// ctx hanya membatasi waktu tunggu; penulisan ke DB tidak terikat pada request.
+func (w *BatchWriter) Enqueue(ctx context.Context, o *order.Order, event *order.OutboxEvent) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return ErrWriterClosed
+	}
+
+	item := pendingWrite{order: o, event: event}
+	select {
+	case w.queue <- item:
+		return nil
+	default:
+	}
+
+	if w.cfg.EnqueueTimeout == 0 {
+		return ErrQueueFull
+	}
+
+	timer := time.NewTimer(w.cfg.EnqueueTimeout)
+	defer timer.Stop()
+	select {
+	case w.queue <- item:
+		return nil
+	case <-timer.C:
+		return ErrQueueFull
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close berhenti menerima order baru, mem-flush sisa buffer dan menunggu semua worker selesai
+func (w *BatchWriter) Close() {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	w.wg.Wait()
+	w.cfg.Logger.Info("batch writer berhenti")
+}
+
+func (w *BatchWriter) worker() {
+	defer w.wg.Done()
+
+	batch := make([]pendingWrite, 0, w.cfg.BatchSize)
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case item, ok := <-w.queue:
+			if !ok {
+				w.flush(batch)
+				return
+			}
+			batch = append(batch, item)
+			if len(batch) >= w.cfg.BatchSize {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		}
+	}
+}
+
+// flush menyimpan batch dalam satu transaksi, dicoba ulang dengan backoff agar
+// gangguan DB sesaat tidak membuang order. Jika tetap gagal, order disimpan satu
+// per satu agar satu baris yang bermasalah tidak menggagalkan seluruh batch.
+func (w *BatchWriter) flush(batch []pendingWrite) {
+	if len(batch) == 0 {
+		return
+	}
+
+	orders := make([]*order.Order, len(batch))
+	events := make([]*order.OutboxEvent, len(batch))
+	for i, item := range batch {
+		orders[i] = item.order
+		events[i] = item.event
+	}
+
+	// Request asal sudah selesai (202), jadi batch ditulis dengan context sendiri
+	ctx := context.Background()
+
+	if err := w.saveBatch(ctx, orders, events); err != nil {
+		w.cfg.Logger.WarnContext(ctx, "gagal menyimpan batch order, fallback ke penyimpanan per order",
+			slog.Int("batch_size", len(batch)), logging.Err(err))
+
+		saved := orders[:0]
+		for _, item := range batch {
+			if _, err := w.repo.SaveWithOutbox(ctx, item.order, item.event); err != nil {
+				w.cfg.Logger.ErrorContext(ctx, "order GAGAL disimpan dan hilang dari antrean",
+					logging.OrderID(item.order.ID), logging.ProductID(item.order.ProductID), logging.Err(err))
+				metrics.OrdersDropped.Inc()
+				continue
+			}
+			saved = append(saved, item.order)
+		}
+		orders = saved
+	}
+
+	if w.onFlush != nil && len(orders) > 0 {
+		w.onFlush(ctx, orders)
+	}
+}
+
+// saveBatch menjalankan SaveBatchWithOutbox sampai MaxRetries kali retry
+// (jeda RetryBaseDelay, 2x, 4x, ...). Transaksi yang gagal di-rollback
+// seluruhnya, jadi batch aman ditulis ulang.
+func (w *BatchWriter) saveBatch(ctx context.Context, orders []*order.Order, events []*order.OutboxEvent) error {
+	delay := w.cfg.RetryBaseDelay
+	for attempt := 0; ; attempt++ {
+		err := w.repo.SaveBatchWithOutbox(ctx, orders, events)
+		if err == nil || attempt >= w.cfg.MaxRetries {
+			return err
+		}
+		w.cfg.Logger.WarnContext(ctx, "gagal menyimpan batch order, dicoba ulang",
+			slog.Int("batch_size", len(orders)), slog.Int("attempt", attempt+1),
+			slog.Duration("retry_delay", delay), logging.Err(err))
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"challenge-order-service/internal/metrics"
+	"challenge-order-service/internal/order"
+	"challenge-order-service/internal/order/repository"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newPendingOrder() (*order.Order, *order.OutboxEvent) {
+	return &order.Order{ID: uuid.New(), ProductID: testProductID, Status: order.StatusPending},
+		&order.OutboxEvent{Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`)}
+}
+
+// flushRecorder mengumpulkan order yang dilaporkan lewat onFlush
+type flushRecorder struct {
+	mu     sync.Mutex
+	orders []*order.Order
+}
+
+func (r *flushRecorder) record(_ context.Context, orders []*order.Order) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orders = append(r.orders, orders...)
+}
+
+func (r *flushRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.orders)
+}
+
+func TestBatchWriter_FlushesFullBatch(t *testing.T) {
+	mockRepo := new(repository.MockOrderRepository)
+	recorder := &flushRecorder{}
+	writer := NewBatchWriter(mockRepo, BatchWriterConfig{BatchSize: 2, FlushInterval: time.Hour, Workers: 1}, recorder.record)
+
+	mockRepo.On("SaveBatchWithOutbox", mock.Anything, mock.MatchedBy(func(orders []*order.Order) bool { return len(orders) == 2 }), mock.Anything).
+		Return(nil).Once()
+
+	writer.Start()
+	for i := 0; i < 2; i++ {
+		o, e := newPendingOrder()
+		assert.NoError(t, writer.Enqueue(ctx, o, e))
+	}
+
+	assert.Eventually(t, func() bool { return recorder.count() == 2 }, time.Second, 5*time.Millisecond)
+	writer.Close()
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBatchWriter_CloseFlushesRemainder(t *testing.T) {
+	mockRepo := new(repository.MockOrderRepository)
+	recorder := &flushRecorder{}
+	writer := NewBatchWriter(mockRepo, BatchWriterConfig{BatchSize: 100, FlushInterval: time.Hour, Workers: 1}, recorder.record)
+
+	mockRepo.On("SaveBatchWithOutbox", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	writer.Start()
+	o, e := newPendingOrder()
+	assert.NoError(t, writer.Enqueue(ctx, o, e))
+	writer.Close()
+
+	assert.Equal(t, 1, recorder.count())
+	assert.ErrorIs(t, writer.Enqueue(ctx, o, e), ErrWriterClosed)
+}
+
+func TestBatchWriter_FallsBackToSingleInserts(t *testing.T) {
+	mockRepo := new(repository.MockOrderRepository)
+	recorder := &flushRecorder{}
+	writer := NewBatchWriter(mockRepo, BatchWriterConfig{BatchSize: 2, FlushInterval: time.Hour, Workers: 1, MaxRetries: -1}, recorder.record)
+
+	good, goodEvent := newPendingOrder()
+	bad, badEvent := newPendingOrder()
+	droppedBefore := testutil.ToFloat64(metrics.OrdersDropped)
+
+	mockRepo.On("SaveBatchWithOutbox", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("constraint violation")).Once()
+	mockRepo.On("SaveWithOutbox", mock.Anything, good, goodEvent).Return(good, nil).Once()
+	mockRepo.On("SaveWithOutbox", mock.Anything, bad, badEvent).Return(nil, errors.New("constraint violation")).Once()
+
+	writer.Start()
+	assert.NoError(t, writer.Enqueue(ctx, good, goodEvent))
+	assert.NoError(t, writer.Enqueue(ctx, bad, badEvent))
+	writer.Close()
+
+	// Hanya order yang berhasil disimpan yang dilaporkan; yang gagal dihitung sebagai dropped
+	assert.Equal(t, 1, recorder.count())
+	assert.Equal(t, good.ID, recorder.orders[0].ID)
+	assert.Equal(t, droppedBefore+1, testutil.ToFloat64(metrics.OrdersDropped))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBatchWriter_RetriesBatchBeforeFallback(t *testing.T) {
+	mockRepo := new(repository.MockOrderRepository)
+	recorder := &flushRecorder{}
+	writer := NewBatchWriter(mockRepo, BatchWriterConfig{
+		BatchSize: 2, FlushInterval: time.Hour, Workers: 1, MaxRetries: 2, RetryBaseDelay: time.Millisecond,
+	}, recorder.record)
+
+	first, firstEvent := newPendingOrder()
+	second, secondEvent := newPendingOrder()
+
+	// DB sempat tidak tersedia: dua percobaan pertama gagal, retry kedua berhasil
+	mockRepo.On("SaveBatchWithOutbox", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("connection refused")).Twice()
+	mockRepo.On("SaveBatchWithOutbox", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	writer.Start()
+	assert.NoError(t, writer.Enqueue(ctx, first, firstEvent))
+	assert.NoError(t, writer.Enqueue(ctx, second, secondEvent))
+	writer.Close()
+
+	assert.Equal(t, 2, recorder.count())
+	mockRepo.AssertNumberOfCalls(t, "SaveBatchWithOutbox", 3)
+	mockRepo.AssertNotCalled(t, "SaveWithOutbox", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBatchWriter_EnqueueReturnsQueueFull(t *testing.T) {
+	mockRepo := new(repository.MockOrderRepository)
+	writer := NewBatchWriter(mockRepo, BatchWriterConfig{BufferSize: 1, EnqueueTimeout: time.Millisecond}, nil)
+
+	// Worker belum dijalankan, jadi slot kedua tidak pernah kosong
+	o, e := newPendingOrder()
+	assert.NoError(t, writer.Enqueue(ctx, o, e))
+	assert.ErrorIs(t, writer.Enqueue(ctx, o, e), ErrQueueFull)
+}
+
+func TestBatchWriter_EnqueueStopsWaitingWhenContextCancelled(t *testing.T) {
+	mockRepo := new(repository.MockOrderRepository)
+	writer := NewBatchWriter(mockRepo, BatchWriterConfig{BufferSize: 1, EnqueueTimeout: time.Hour}, nil)
+
+	o, e := newPendingOrder()
+	assert.NoError(t, writer.Enqueue(ctx, o, e))
+
+	// Request dibatalkan klien: Enqueue tidak boleh menunggu sampai EnqueueTimeout
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, writer.Enqueue(cancelled, o, e), context.Canceled)
+}
@@ -1,37 +1,42 @@
 package service
 
 import (
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/metrics"
 	"challenge-order-service/internal/order"
 	"challenge-order-service/internal/order/repository"
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
-	"net/http"
-	"sync" // <-- Impor baru untuk cache yang aman
+	"log/slog"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
-	"github.com/streadway/amqp"
 )
 
-// Konteks global untuk Redis
-var ctx = context.Background()
-
 // --- INTERFACES UNTUK MOCKING ---
 type Publisher interface {
-	Publish(exchange, routingKey string, body []byte) error
+	Publish(ctx context.Context, exchange, routingKey string, body []byte) error
 }
 
 type ProductServiceClient interface {
-	GetProductInfo(productID uuid.UUID) (*ProductResponse, error)
+	GetProductInfo(ctx context.Context, productID uuid.UUID) (*ProductResponse, error)
+	// GetFreshProductInfo melewati cache, dipakai saat stok di cache mepet dengan permintaan
+	GetFreshProductInfo(ctx context.Context, productID uuid.UUID) (*ProductResponse, error)
 }
 
 // --- CORE SERVICE DEFINITIONS ---
 type OrderService interface {
-	CreateOrder(req order.CreateOrderRequest) (*order.Order, error)
-	GetOrdersByProductID(productID uuid.UUID) ([]order.Order, error)
+	CreateOrder(ctx context.Context, req order.CreateOrderRequest) (*order.Order, error)
+	GetOrderByID(ctx context.Context, id uuid.UUID) (*order.Order, error)
+	GetOrdersByProductID(ctx context.Context, productID uuid.UUID, query order.OrderListQuery) (*order.OrderPage, error)
+	UpdateOrderStatus(ctx context.Context, id uuid.UUID, status order.OrderStatus) (*order.Order, error)
+	// FinalizeOrder memindahkan order ke status akhir (PROCESSED/FAILED) berdasarkan
+	// hasil reservasi stok dari product-service, lalu menulis event final ke outbox
+	FinalizeOrder(ctx context.Context, id uuid.UUID, status order.OrderStatus, reason string) (*order.Order, error)
+	// AsyncMode bernilai true jika CreateOrder hanya mengantrekan order (HTTP 202)
+	AsyncMode() bool
 }
 
 type ProductResponse struct {
@@ -45,189 +50,443 @@ type ProductResponse struct {
 type orderService struct {
 	repo          repository.OrderRepository
 	rdb           *redis.Client
-	publisher     Publisher
 	productClient ProductServiceClient
+	batchWriter   *BatchWriter // nil = mode sinkron
+	logger        *slog.Logger
+	cacheLogger   *slog.Logger // untuk baris CACHE HIT/MISS yang sangat sering (boleh di-sampling)
+
+	stockRefreshMargin int           // sisa stok (setelah order) yang memicu pengambilan ulang dari product-service
+	cacheTTL           time.Duration // umur cache 'order:<id>' dan 'orders_by_product:<id>'
+	ordersExchange     string        // exchange tujuan event order.*
+}
+
+const (
+	// DefaultStockRefreshMargin: jika stok di cache kurang dari jumlah pesanan + margin ini,
+	// stok diambil ulang dari product-service sebelum order diterima/ditolak.
+	DefaultStockRefreshMargin = 5
+	// DefaultOrderCacheTTL adalah umur cache order di Redis
+	DefaultOrderCacheTTL = 10 * time.Minute
+	// DefaultOrdersExchange adalah exchange (topic) untuk event order.* dan stock.*
+	DefaultOrdersExchange = "orders_exchange"
+)
+
+// OrderServiceOption mengatur fitur opsional orderService
+type OrderServiceOption func(*orderService)
+
+// WithBatchWriter mengaktifkan mode asinkron: CreateOrder hanya memvalidasi dan
+// mengantrekan order, penulisan DB dilakukan BatchWriter secara batch.
+func WithBatchWriter(w *BatchWriter) OrderServiceOption {
+	return func(s *orderService) {
+		s.batchWriter = w
+	}
+}
+
+// WithLogger mengganti logger (default slog.Default()); baris CACHE HIT/MISS
+// ikut memakai logger ini kecuali diganti WithCacheLogger
+func WithLogger(logger *slog.Logger) OrderServiceOption {
+	return func(s *orderService) {
+		s.logger = logger
+	}
+}
+
+// WithCacheLogger memakai logger terpisah (mis. logging.Sampled) untuk baris CACHE HIT/MISS
+func WithCacheLogger(logger *slog.Logger) OrderServiceOption {
+	return func(s *orderService) {
+		s.cacheLogger = logger
+	}
+}
+
+// WithStockRefreshMargin mengganti DefaultStockRefreshMargin (0 = hanya saat stok di cache tidak cukup)
+func WithStockRefreshMargin(margin int) OrderServiceOption {
+	return func(s *orderService) {
+		if margin >= 0 {
+			s.stockRefreshMargin = margin
+		}
+	}
+}
+
+// WithCacheTTL mengganti DefaultOrderCacheTTL (<= 0 diabaikan)
+func WithCacheTTL(ttl time.Duration) OrderServiceOption {
+	return func(s *orderService) {
+		if ttl > 0 {
+			s.cacheTTL = ttl
+		}
+	}
+}
+
+// WithOrdersExchange mengganti DefaultOrdersExchange ("" diabaikan)
+func WithOrdersExchange(exchange string) OrderServiceOption {
+	return func(s *orderService) {
+		if exchange != "" {
+			s.ordersExchange = exchange
+		}
+	}
 }
 
 // 3. Buat "Constructor"
 func NewOrderService(
 	repo repository.OrderRepository,
 	rdb *redis.Client,
-	publisher Publisher,
 	productClient ProductServiceClient,
+	opts ...OrderServiceOption,
 ) OrderService {
-	return &orderService{
+	s := &orderService{
 		repo:          repo,
 		rdb:           rdb,
-		publisher:     publisher,
 		productClient: productClient,
+		logger:        slog.Default(),
+
+		stockRefreshMargin: DefaultStockRefreshMargin,
+		cacheTTL:           DefaultOrderCacheTTL,
+		ordersExchange:     DefaultOrdersExchange,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.cacheLogger == nil {
+		s.cacheLogger = s.logger
+	}
+	if s.batchWriter != nil {
+		// Cache per produk baru dihapus setelah batch benar-benar tersimpan
+		s.batchWriter.SetOnFlush(s.invalidateProductCaches)
+	}
+	return s
+}
+
+func (s *orderService) AsyncMode() bool {
+	return s.batchWriter != nil
 }
 
 // 4. Implementasi "CreateOrder"
-func (s *orderService) CreateOrder(req order.CreateOrderRequest) (*order.Order, error) {
+func (s *orderService) CreateOrder(ctx context.Context, req order.CreateOrderRequest) (*order.Order, error) {
 
-	// Panggil service client (yang sekarang punya cache-nya sendiri)
-	product, err := s.productClient.GetProductInfo(req.ProductID)
+	lines, err := req.Lines()
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrValidation, err)
 	}
 
-	if product.Qty < req.Quantity {
-		return nil, fmt.Errorf("stok produk %s tidak mencukupi", req.ProductID.String())
-	}
+	orderID := uuid.New()
+	items := make([]order.OrderItem, 0, len(lines))
+	totalPrice := 0.0
 
-	totalPrice := product.Price * float64(req.Quantity)
+	// Validasi stok & harga per item lewat service client (yang punya cache-nya sendiri)
+	for _, line := range lines {
+		product, err := s.productClient.GetProductInfo(ctx, line.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Stok di cache bisa basi: ambil ulang jika sisa stok mepet dengan jumlah pesanan
+		if product.Qty < line.Quantity+s.stockRefreshMargin {
+			product, err = s.productClient.GetFreshProductInfo(ctx, line.ProductID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if product.Qty < line.Quantity {
+			return nil, fmt.Errorf("%w: produk %s (tersedia %d, dipesan %d)",
+				ErrInsufficientStock, line.ProductID.String(), product.Qty, line.Quantity)
+		}
+
+		subtotal := product.Price * float64(line.Quantity)
+		totalPrice += subtotal
+		items = append(items, order.OrderItem{
+			ID:          uuid.New(),
+			OrderID:     orderID,
+			ProductID:   line.ProductID,
+			ProductName: product.Name,
+			Quantity:    line.Quantity,
+			UnitPrice:   product.Price,
+			Subtotal:    subtotal,
+		})
+	}
 
+	// Kolom produk di level order mengacu ke item pertama (kompatibilitas API lama)
+	first := items[0]
 	newOrder := &order.Order{
-		ID:         uuid.New(),
-		ProductID:  req.ProductID,
-		TotalPrice: totalPrice,
-		Status:     order.StatusPending,
+		ID:          orderID,
+		ProductID:   first.ProductID,
+		TotalPrice:  totalPrice,
+		Status:      order.StatusPending,
+		Quantity:    first.Quantity,
+		UnitPrice:   first.UnitPrice,
+		ProductName: first.ProductName,
+		Items:       items,
 	}
 
-	// Simpan ke DB
-	savedOrder, err := s.repo.Save(newOrder)
-	if err != nil {
-		return nil, fmt.Errorf("gagal menyimpan order: %w", err)
+	// Simpan order + event 'order.created' ke outbox dalam satu transaksi.
+	// Publish ke RabbitMQ dilakukan oleh OutboxRelay di background.
+	event := &order.OutboxEvent{
+		Exchange:   s.ordersExchange,
+		RoutingKey: "order.created",
+		Payload:    s.createEventBody(newOrder),
+		Headers:    outboxHeaders(ctx),
 	}
 
-	// Publish event
-	err = s.publisher.Publish("orders_exchange", "order.created", s.createEventBody(savedOrder, req.Quantity))
+	// Mode asinkron: order dikembalikan setelah masuk antrean, belum tersimpan di DB
+	if s.batchWriter != nil {
+		newOrder.CreatedAt = time.Now()
+		if err := s.batchWriter.Enqueue(ctx, newOrder, event); err != nil {
+			return nil, err
+		}
+		s.logger.InfoContext(ctx, "order diantrekan",
+			logging.OrderID(newOrder.ID), logging.ProductID(newOrder.ProductID), slog.Int("items", len(items)))
+		return newOrder, nil
+	}
+
+	savedOrder, err := s.repo.SaveWithOutbox(ctx, newOrder, event)
 	if err != nil {
-		log.Printf("PERINGATAN: Order %s berhasil disimpan, tapi GAGAL publish event: %v", savedOrder.ID, err)
+		return nil, fmt.Errorf("gagal menyimpan order: %w", err)
 	}
+	s.logger.InfoContext(ctx, "order dibuat",
+		logging.OrderID(savedOrder.ID), logging.ProductID(savedOrder.ProductID), slog.Int("items", len(items)))
 
-	// Hapus cache 'GetOrdersByProductID' (ini masih di Redis, tidak apa-apa)
-	cacheKey := fmt.Sprintf("orders_by_product:%s", req.ProductID.String())
-	s.rdb.Del(ctx, cacheKey)
+	// Hapus cache 'GetOrdersByProductID' untuk setiap produk di order
+	s.invalidateProductCaches(ctx, []*order.Order{savedOrder})
 
 	return savedOrder, nil
 }
 
 // 5. Implementasi "GetOrdersByProductID"
-func (s *orderService) GetOrdersByProductID(productID uuid.UUID) ([]order.Order, error) {
+// Cache disimpan sebagai Redis HASH 'orders_by_product:<productID>' dengan satu field
+// per bentuk query (filter + cursor), sehingga satu DEL dari CreateOrder /
+// UpdateOrderStatus menghapus semua halaman untuk produk tersebut.
+func (s *orderService) GetOrdersByProductID(ctx context.Context, productID uuid.UUID, query order.OrderListQuery) (*order.OrderPage, error) {
+	query, err := query.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
 	cacheKey := fmt.Sprintf("orders_by_product:%s", productID.String())
+	cacheField := query.CacheField()
 
-	val, err := s.rdb.Get(ctx, cacheKey).Result()
+	val, err := s.rdb.HGet(ctx, cacheKey, cacheField).Result()
 	if err == nil {
-		log.Println("CACHE HIT untuk GetOrdersByProductID:", productID)
-		var orders []order.Order
-		if json.Unmarshal([]byte(val), &orders) == nil {
-			return orders, nil
+		s.cacheLogger.DebugContext(ctx, "CACHE HIT",
+			slog.String("cache", metrics.CacheOrdersByProduct), logging.ProductID(productID), slog.String("field", cacheField))
+		var page order.OrderPage
+		if json.Unmarshal([]byte(val), &page) == nil {
+			metrics.CacheHit(metrics.CacheOrdersByProduct)
+			return &page, nil
 		}
 	}
-	log.Println("CACHE MISS untuk GetOrdersByProductID:", productID)
+	s.cacheLogger.DebugContext(ctx, "CACHE MISS",
+		slog.String("cache", metrics.CacheOrdersByProduct), logging.ProductID(productID), slog.String("field", cacheField))
+	metrics.CacheMiss(metrics.CacheOrdersByProduct)
 
-	orders, err := s.repo.FindByProductID(productID)
+	page, err := s.repo.FindByProductID(ctx, productID, query)
 	if err != nil {
 		return nil, err
 	}
 
-	jsonData, _ := json.Marshal(orders)
-	s.rdb.Set(ctx, cacheKey, jsonData, 10*time.Minute)
-	return orders, nil
+	jsonData, _ := json.Marshal(page)
+	pipe := s.rdb.TxPipeline()
+	pipe.HSet(ctx, cacheKey, cacheField, jsonData)
+	pipe.Expire(ctx, cacheKey, s.cacheTTL)
+	pipe.Exec(ctx)
+	return page, nil
 }
 
-// --- FUNGSI HELPER & IMPLEMENTASI CONCRETE UNTUK main.go ---
+// 6. Implementasi "GetOrderByID" (pola cache sama dengan GetOrdersByProductID)
+func (s *orderService) GetOrderByID(ctx context.Context, id uuid.UUID) (*order.Order, error) {
+	cacheKey := fmt.Sprintf("order:%s", id.String())
 
-// createEventBody membuat payload event RabbitMQ
-func (s *orderService) createEventBody(order *order.Order, quantity int) []byte {
-	// ... (kode ini sama seperti sebelumnya, tidak perlu diubah)
-	event := struct {
-		OrderID         string `json:"orderId"`
-		ProductID       string `json:"productId"`
-		QuantityOrdered int    `json:"quantityOrdered"`
-		Timestamp       string `json:"timestamp"`
-	}{
-		OrderID:         order.ID.String(),
-		ProductID:       order.ProductID.String(),
-		QuantityOrdered: quantity,
-		Timestamp:       time.Now().Format(time.RFC3339),
+	val, err := s.rdb.Get(ctx, cacheKey).Result()
+	if err == nil {
+		s.cacheLogger.DebugContext(ctx, "CACHE HIT", slog.String("cache", metrics.CacheOrder), logging.OrderID(id))
+		var cached order.Order
+		if json.Unmarshal([]byte(val), &cached) == nil {
+			metrics.CacheHit(metrics.CacheOrder)
+			return &cached, nil
+		}
 	}
-	body, _ := json.Marshal(event)
-	return body
-}
+	s.cacheLogger.DebugContext(ctx, "CACHE MISS", slog.String("cache", metrics.CacheOrder), logging.OrderID(id))
+	metrics.CacheMiss(metrics.CacheOrder)
 
-// ===================================================================
-// === PERBAIKAN SOLUSI LAIN: BUAT CACHE IN-MEMORY DI SINI ===
-// ===================================================================
+	// order.ErrOrderNotFound diteruskan apa adanya dan TIDAK di-cache
+	found, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
 
-// ProductClientImpl sekarang memiliki cache map-nya sendiri
-type ProductClientImpl struct {
-	productCache map[uuid.UUID]*ProductResponse // Cache in-memory kita
-	mu           sync.RWMutex                   // Mutex untuk melindungi map
+	jsonData, _ := json.Marshal(found)
+	s.rdb.Set(ctx, cacheKey, jsonData, s.cacheTTL)
+	return found, nil
 }
 
-// NewProductClientImpl TIDAK LAGI MEMBUTUHKAN REDIS
-func NewProductClientImpl() *ProductClientImpl {
-	return &ProductClientImpl{
-		// Inisialisasi map
-		productCache: make(map[uuid.UUID]*ProductResponse),
+// 7. Implementasi "UpdateOrderStatus"
+func (s *orderService) UpdateOrderStatus(ctx context.Context, id uuid.UUID, status order.OrderStatus) (*order.Order, error) {
+	if !status.IsValid() {
+		return nil, fmt.Errorf("%w: %q", order.ErrInvalidStatus, status)
 	}
-}
 
-// GetProductInfo sekarang menggunakan Go map, BUKAN Redis
-func (c *ProductClientImpl) GetProductInfo(productID uuid.UUID) (*ProductResponse, error) {
+	// Repository menolak transisi ilegal di dalam transaksi DB; event
+	// 'order.status_changed' ditulis ke outbox dalam transaksi yang sama
+	updatedOrder, _, err := s.repo.UpdateStatus(ctx, id, status, func(updated *order.Order, previous order.OrderStatus) *order.OutboxEvent {
+		return &order.OutboxEvent{
+			Exchange:   s.ordersExchange,
+			RoutingKey: "order.status_changed",
+			Payload:    s.createStatusChangedEventBody(updated, previous),
+			Headers:    outboxHeaders(ctx),
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	// 1. Coba Cache Read (dari Go map)
-	c.mu.RLock() // Kunci untuk membaca
-	product, found := c.productCache[productID]
-	c.mu.RUnlock() // Buka kunci
+	// Cache order tunggal dan daftar order per produk ikut menyimpan status, jadi harus dihapus
+	s.rdb.Del(ctx, fmt.Sprintf("order:%s", updatedOrder.ID.String()))
+	s.invalidateProductCaches(ctx, []*order.Order{updatedOrder})
 
-	if found {
-		log.Println("IN-MEMORY CACHE HIT (Product Info):", productID)
-		return product, nil
+	return updatedOrder, nil
+}
+
+// 8. Implementasi "FinalizeOrder"
+func (s *orderService) FinalizeOrder(ctx context.Context, id uuid.UUID, status order.OrderStatus, reason string) (*order.Order, error) {
+	if !status.IsTerminal() {
+		return nil, fmt.Errorf("%w: %q bukan status akhir", order.ErrInvalidStatus, status)
 	}
 
-	// 2. HTTP Fallback (Cache Miss)
-	log.Println("IN-MEMORY CACHE MISS (Product Info):", productID)
-	productServiceURL := fmt.Sprintf("http://product-service:3000/products/%s", productID.String())
+	routingKey := OrderProcessedRoutingKey
+	if status == order.StatusFailed {
+		routingKey = OrderFailedRoutingKey
+	}
 
-	resp, err := http.Get(productServiceURL)
+	// Repository menolak transisi ilegal (termasuk order yang sudah final);
+	// event final ditulis ke outbox dalam transaksi yang sama dengan status
+	updatedOrder, _, err := s.repo.UpdateStatus(ctx, id, status, func(updated *order.Order, _ order.OrderStatus) *order.OutboxEvent {
+		return &order.OutboxEvent{
+			Exchange:   s.ordersExchange,
+			RoutingKey: routingKey,
+			Payload:    s.createFinalizedEventBody(updated, reason),
+			Headers:    outboxHeaders(ctx),
+		}
+	})
 	if err != nil {
-		return nil, fmt.Errorf("gagal menghubungi product-service: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("product-service mengembalikan error %d", resp.StatusCode)
-	}
+	s.rdb.Del(ctx, fmt.Sprintf("order:%s", updatedOrder.ID.String()))
+	s.invalidateProductCaches(ctx, []*order.Order{updatedOrder})
 
-	var newProduct ProductResponse
-	if err := json.NewDecoder(resp.Body).Decode(&newProduct); err != nil {
-		return nil, fmt.Errorf("gagal decode respons product-service: %w", err)
-	}
+	return updatedOrder, nil
+}
 
-	// 3. Tulis ke Cache (Go map)
-	c.mu.Lock() // Kunci untuk menulis
-	c.productCache[productID] = &newProduct
-	c.mu.Unlock() // Buka kunci
+// --- FUNGSI HELPER & IMPLEMENTASI CONCRETE UNTUK main.go ---
 
-	return &newProduct, nil
+// orderCreatedItem adalah satu baris di payload event 'order.created'
+type orderCreatedItem struct {
+	ProductID       string  `json:"productId"`
+	ProductName     string  `json:"productName"`
+	QuantityOrdered int     `json:"quantityOrdered"`
+	UnitPrice       float64 `json:"unitPrice"`
+	Subtotal        float64 `json:"subtotal"`
 }
 
-// ===================================================================
+// createEventBody membuat payload event RabbitMQ dari snapshot yang tersimpan di order,
+// sehingga event yang sama bisa dibangun ulang dari order historis.
+// Field productId/quantityOrdered di level atas mengacu ke item pertama untuk consumer lama;
+// consumer baru sebaiknya mengurangi stok berdasarkan 'items'.
+func (s *orderService) createEventBody(order *order.Order) []byte {
+	items := eventItems(order)
 
-// PublisherImpl mengimplementasikan Publisher (CONCRETE)
-// (Kode ini sama seperti sebelumnya, tidak perlu diubah)
-type PublisherImpl struct {
-	ch *amqp.Channel
+	event := struct {
+		OrderID         string             `json:"orderId"`
+		ProductID       string             `json:"productId"`
+		ProductName     string             `json:"productName"`
+		QuantityOrdered int                `json:"quantityOrdered"`
+		UnitPrice       float64            `json:"unitPrice"`
+		TotalPrice      float64            `json:"totalPrice"`
+		Items           []orderCreatedItem `json:"items"`
+		Timestamp       string             `json:"timestamp"`
+	}{
+		OrderID:         order.ID.String(),
+		ProductID:       order.ProductID.String(),
+		ProductName:     order.ProductName,
+		QuantityOrdered: order.Quantity,
+		UnitPrice:       order.UnitPrice,
+		TotalPrice:      order.TotalPrice,
+		Items:           items,
+		Timestamp:       time.Now().Format(time.RFC3339),
+	}
+	body, _ := json.Marshal(event)
+	return body
+}
+
+// createFinalizedEventBody membuat payload event 'order.processed' / 'order.failed'.
+// Item ikut dikirim agar product-service bisa melepas reservasi stok order yang gagal.
+func (s *orderService) createFinalizedEventBody(order *order.Order, reason string) []byte {
+	event := struct {
+		OrderID    string             `json:"orderId"`
+		ProductID  string             `json:"productId"`
+		Status     string             `json:"status"`
+		Reason     string             `json:"reason,omitempty"`
+		TotalPrice float64            `json:"totalPrice"`
+		Items      []orderCreatedItem `json:"items"`
+		Timestamp  string             `json:"timestamp"`
+	}{
+		OrderID:    order.ID.String(),
+		ProductID:  order.ProductID.String(),
+		Status:     string(order.Status),
+		Reason:     reason,
+		TotalPrice: order.TotalPrice,
+		Items:      eventItems(order),
+		Timestamp:  time.Now().Format(time.RFC3339),
+	}
+	body, _ := json.Marshal(event)
+	return body
 }
 
-func NewPublisherImpl(ch *amqp.Channel) *PublisherImpl {
-	return &PublisherImpl{ch: ch}
+// eventItems mengubah item order menjadi baris payload event
+func eventItems(order *order.Order) []orderCreatedItem {
+	items := make([]orderCreatedItem, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, orderCreatedItem{
+			ProductID:       item.ProductID.String(),
+			ProductName:     item.ProductName,
+			QuantityOrdered: item.Quantity,
+			UnitPrice:       item.UnitPrice,
+			Subtotal:        item.Subtotal,
+		})
+	}
+	return items
 }
 
-func (p *PublisherImpl) Publish(exchange, routingKey string, body []byte) error {
-	return p.ch.Publish(
-		exchange,
-		routingKey,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        body,
-		},
-	)
+// invalidateProductCaches menghapus cache 'orders_by_product' untuk setiap produk
+// (termasuk semua item) dalam batch dengan satu perintah DEL
+func (s *orderService) invalidateProductCaches(ctx context.Context, orders []*order.Order) {
+	seen := make(map[uuid.UUID]struct{}, len(orders))
+	keys := make([]string, 0, len(orders))
+	for _, o := range orders {
+		for _, productID := range o.ProductIDs() {
+			if _, ok := seen[productID]; ok {
+				continue
+			}
+			seen[productID] = struct{}{}
+			keys = append(keys, fmt.Sprintf("orders_by_product:%s", productID.String()))
+		}
+	}
+	if len(keys) > 0 {
+		s.rdb.Del(ctx, keys...)
+	}
+}
+
+// createStatusChangedEventBody membuat payload event 'order.status_changed'
+func (s *orderService) createStatusChangedEventBody(order *order.Order, previous order.OrderStatus) []byte {
+	event := struct {
+		OrderID        string `json:"orderId"`
+		ProductID      string `json:"productId"`
+		PreviousStatus string `json:"previousStatus"`
+		Status         string `json:"status"`
+		Timestamp      string `json:"timestamp"`
+	}{
+		OrderID:        order.ID.String(),
+		ProductID:      order.ProductID.String(),
+		PreviousStatus: string(previous),
+		Status:         string(order.Status),
+		Timestamp:      time.Now().Format(time.RFC3339),
+	}
+	body, _ := json.Marshal(event)
+	return body
 }
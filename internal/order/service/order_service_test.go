@@ -1,10 +1,12 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"challenge-order-service/internal/order"
 	"challenge-order-service/internal/order/repository"
@@ -16,6 +18,8 @@ import (
 	"github.com/stretchr/testify/mock"
 )
 
+var ctx = context.Background()
+
 // --- MOCK DEFINITIONS ---
 
 // MockProductService adalah mock untuk interface ProductServiceClient
@@ -23,8 +27,16 @@ type MockProductService struct {
 	mock.Mock
 }
 
-func (m *MockProductService) GetProductInfo(productID uuid.UUID) (*ProductResponse, error) {
-	args := m.Called(productID)
+func (m *MockProductService) GetProductInfo(ctx context.Context, productID uuid.UUID) (*ProductResponse, error) {
+	args := m.Called(ctx, productID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ProductResponse), args.Error(1)
+}
+
+func (m *MockProductService) GetFreshProductInfo(ctx context.Context, productID uuid.UUID) (*ProductResponse, error) {
+	args := m.Called(ctx, productID)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -36,8 +48,8 @@ type MockPublisher struct {
 	mock.Mock
 }
 
-func (m *MockPublisher) Publish(exchange, routingKey string, body []byte) error {
-	args := m.Called(exchange, routingKey, body)
+func (m *MockPublisher) Publish(ctx context.Context, exchange, routingKey string, body []byte) error {
+	args := m.Called(ctx, exchange, routingKey, body)
 	return args.Error(0)
 }
 
@@ -63,10 +75,9 @@ func getProductCacheKey(id uuid.UUID) string {
 
 // --- TEST SETUP ---
 
-func setupTest(t *testing.T) (OrderService, *repository.MockOrderRepository, *MockPublisher, *miniredis.Miniredis, *MockProductService) {
-	// 1. Setup Mock Repository & Publisher & Product Client
+func setupTest(t *testing.T) (OrderService, *repository.MockOrderRepository, *miniredis.Miniredis, *MockProductService) {
+	// 1. Setup Mock Repository & Product Client
 	mockRepo := new(repository.MockOrderRepository)
-	mockPublisher := new(MockPublisher)
 	mockProductClient := new(MockProductService)
 
 	// 2. Setup Mock Redis (miniredis)
@@ -76,10 +87,10 @@ func setupTest(t *testing.T) (OrderService, *repository.MockOrderRepository, *Mo
 	}
 	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
 
-	// 3. Create Service - Menggunakan 4 ARGUMEN SESUAI CONSTRUCTOR BARU
-	svc := NewOrderService(mockRepo, rdb, mockPublisher, mockProductClient)
+	// 3. Create Service
+	svc := NewOrderService(mockRepo, rdb, mockProductClient)
 
-	return svc, mockRepo, mockPublisher, mr, mockProductClient
+	return svc, mockRepo, mr, mockProductClient
 }
 
 // --- TEST CASES: CreateOrder ---
@@ -87,7 +98,7 @@ func setupTest(t *testing.T) (OrderService, *repository.MockOrderRepository, *Mo
 // Diubah namanya menjadi Success biasa, karena kita mem-mock klien produk secara langsung
 func TestOrderService_CreateOrder_Success(t *testing.T) {
 	// PENTING: Gunakan mockProductClient
-	svc, mockRepo, mockPublisher, mr, mockProductClient := setupTest(t)
+	svc, mockRepo, mr, mockProductClient := setupTest(t)
 	defer mr.Close()
 
 	// 1. Arrange: Siapkan data produk untuk di-mock
@@ -102,21 +113,27 @@ func TestOrderService_CreateOrder_Success(t *testing.T) {
 	}
 
 	// 2. Arrange: Mock Product Client (FIX: Menambahkan ekspektasi yang hilang)
-	mockProductClient.On("GetProductInfo", testProductID).
+	mockProductClient.On("GetProductInfo", mock.Anything, testProductID).
 		Return(&productInfo, nil).Once()
 
-	// 3. Arrange: Mock Repository (Save berhasil)
-	mockRepo.On("Save", mock.AnythingOfType("*order.Order")).
-		Return(expectedOrder, nil).Once()
-
-	// 4. Mock Publisher (Publish berhasil)
-	mockPublisher.On("Publish", "orders_exchange", "order.created", mock.AnythingOfType("[]uint8")).
-		Return(nil).Once()
+	// 3. Arrange: Mock Repository (order + event outbox tersimpan dalam satu transaksi)
+	// Order harus membawa snapshot quantity, harga satuan dan nama produk
+	mockRepo.On("SaveWithOutbox", mock.Anything, mock.MatchedBy(func(o *order.Order) bool {
+		return o.Quantity == testQuantity && o.UnitPrice == testPrice && o.ProductName == "Test Product"
+	}), mock.MatchedBy(func(event *order.OutboxEvent) bool {
+		var payload struct {
+			QuantityOrdered int     `json:"quantityOrdered"`
+			UnitPrice       float64 `json:"unitPrice"`
+		}
+		return event.Exchange == "orders_exchange" && event.RoutingKey == "order.created" &&
+			json.Unmarshal(event.Payload, &payload) == nil &&
+			payload.QuantityOrdered == testQuantity && payload.UnitPrice == testPrice
+	})).Return(expectedOrder, nil).Once()
 
 	// 5. Act
 	createReq := order.CreateOrderRequest{ProductID: testProductID, Quantity: testQuantity}
 
-	newOrder, err := svc.CreateOrder(createReq)
+	newOrder, err := svc.CreateOrder(ctx, createReq)
 
 	// 6. Assert
 	assert.NoError(t, err)
@@ -125,22 +142,159 @@ func TestOrderService_CreateOrder_Success(t *testing.T) {
 	// Verifikasi mock yang dipanggil (Pastikan GetProductInfo dipanggil)
 	mockProductClient.AssertExpectations(t)
 	mockRepo.AssertExpectations(t)
-	mockPublisher.AssertExpectations(t)
+}
+
+func TestOrderService_CreateOrder_MultipleItems(t *testing.T) {
+	svc, mockRepo, mr, mockProductClient := setupTest(t)
+	defer mr.Close()
+
+	secondProductID := uuid.New()
+	mockProductClient.On("GetProductInfo", mock.Anything, testProductID).
+		Return(&ProductResponse{ID: testProductID, Name: "Laptop", Price: 100, Qty: 10}, nil).Once()
+	mockProductClient.On("GetProductInfo", mock.Anything, secondProductID).
+		Return(&ProductResponse{ID: secondProductID, Name: "Mouse", Price: 25, Qty: 10}, nil).Once()
+	mockRepo.On("SaveWithOutbox", mock.Anything, mock.AnythingOfType("*order.Order"), mock.AnythingOfType("*order.OutboxEvent")).
+		Return(&order.Order{ID: testOrderID, ProductID: testProductID, Items: []order.OrderItem{
+			{ProductID: testProductID}, {ProductID: secondProductID},
+		}}, nil).Once()
+
+	mr.Set(getOrdersCacheKey(testProductID), "[]")
+	mr.Set(getOrdersCacheKey(secondProductID), "[]")
+
+	_, err := svc.CreateOrder(ctx, order.CreateOrderRequest{Items: []order.CreateOrderItemRequest{
+		{ProductID: testProductID, Quantity: 2},
+		{ProductID: secondProductID, Quantity: 4},
+	}})
+
+	assert.NoError(t, err)
+
+	// Order yang dikirim ke repository berisi semua item dengan total gabungan
+	newOrder := mockRepo.Calls[0].Arguments.Get(1).(*order.Order)
+	assert.Len(t, newOrder.Items, 2)
+	assert.Equal(t, 300.0, newOrder.TotalPrice) // 2*100 + 4*25
+	assert.Equal(t, 100.0, newOrder.Items[1].Subtotal)
+
+	// Event 'order.created' membawa semua baris
+	event := mockRepo.Calls[0].Arguments.Get(2).(*order.OutboxEvent)
+	var payload struct {
+		Items []struct {
+			ProductID       string `json:"productId"`
+			QuantityOrdered int    `json:"quantityOrdered"`
+		} `json:"items"`
+	}
+	assert.NoError(t, json.Unmarshal(event.Payload, &payload))
+	assert.Len(t, payload.Items, 2)
+	assert.Equal(t, secondProductID.String(), payload.Items[1].ProductID)
+	assert.Equal(t, 4, payload.Items[1].QuantityOrdered)
+
+	// Cache semua produk di order dihapus
+	assert.False(t, mr.Exists(getOrdersCacheKey(testProductID)))
+	assert.False(t, mr.Exists(getOrdersCacheKey(secondProductID)))
+}
+
+func TestOrderService_CreateOrder_InsufficientStockOnAnyItem(t *testing.T) {
+	svc, mockRepo, mr, mockProductClient := setupTest(t)
+	defer mr.Close()
+
+	secondProductID := uuid.New()
+	mockProductClient.On("GetProductInfo", mock.Anything, testProductID).
+		Return(&ProductResponse{ID: testProductID, Price: 100, Qty: 10}, nil).Once()
+	mockProductClient.On("GetProductInfo", mock.Anything, secondProductID).
+		Return(&ProductResponse{ID: secondProductID, Price: 25, Qty: 1}, nil).Once()
+	// Stok di cache tidak cukup: dipastikan ulang ke product-service sebelum ditolak
+	mockProductClient.On("GetFreshProductInfo", mock.Anything, secondProductID).
+		Return(&ProductResponse{ID: secondProductID, Price: 25, Qty: 1}, nil).Once()
+
+	_, err := svc.CreateOrder(ctx, order.CreateOrderRequest{Items: []order.CreateOrderItemRequest{
+		{ProductID: testProductID, Quantity: 2},
+		{ProductID: secondProductID, Quantity: 4},
+	}})
+
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+	mockRepo.AssertNotCalled(t, "SaveWithOutbox", mock.Anything, mock.Anything)
+	mockProductClient.AssertExpectations(t)
+}
+
+func TestOrderService_CreateOrder_InvalidRequestIsValidationError(t *testing.T) {
+	svc, _, mr, mockProductClient := setupTest(t)
+	defer mr.Close()
+
+	_, err := svc.CreateOrder(ctx, order.CreateOrderRequest{
+		ProductID: testProductID,
+		Quantity:  1,
+		Items:     []order.CreateOrderItemRequest{{ProductID: testProductID, Quantity: 1}},
+	})
+
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.ErrorIs(t, err, order.ErrInvalidOrderRequest)
+	mockProductClient.AssertNotCalled(t, "GetProductInfo", mock.Anything, mock.Anything)
+}
+
+func TestOrderService_CreateOrder_RefreshesStockNearRequestedQuantity(t *testing.T) {
+	svc, mockRepo, mr, mockProductClient := setupTest(t)
+	defer mr.Close()
+
+	// Cache masih mencatat stok lama (3), product-service sudah punya 20
+	mockProductClient.On("GetProductInfo", mock.Anything, testProductID).
+		Return(&ProductResponse{ID: testProductID, Price: 100, Qty: 3}, nil).Once()
+	mockProductClient.On("GetFreshProductInfo", mock.Anything, testProductID).
+		Return(&ProductResponse{ID: testProductID, Price: 110, Qty: 20}, nil).Once()
+	mockRepo.On("SaveWithOutbox", mock.Anything, mock.AnythingOfType("*order.Order"), mock.AnythingOfType("*order.OutboxEvent")).
+		Return(&order.Order{ID: uuid.New(), ProductID: testProductID}, nil).Once()
+
+	_, err := svc.CreateOrder(ctx, order.CreateOrderRequest{ProductID: testProductID, Quantity: 3})
+
+	assert.NoError(t, err)
+	// Harga yang dipakai berasal dari data terbaru
+	saved := mockRepo.Calls[0].Arguments.Get(1).(*order.Order)
+	assert.Equal(t, 330.0, saved.TotalPrice)
+	mockProductClient.AssertExpectations(t)
+}
+
+func TestOrderService_CreateOrder_AsyncMode(t *testing.T) {
+	mockRepo := new(repository.MockOrderRepository)
+	mockProductClient := new(MockProductService)
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	writer := NewBatchWriter(mockRepo, BatchWriterConfig{BatchSize: 1, Workers: 1}, nil)
+	svc := NewOrderService(mockRepo, rdb, mockProductClient, WithBatchWriter(writer))
+	assert.True(t, svc.AsyncMode())
+
+	mr.Set(getOrdersCacheKey(testProductID), "[]")
+	mockProductClient.On("GetProductInfo", mock.Anything, testProductID).
+		Return(&ProductResponse{ID: testProductID, Price: testPrice, Qty: 50}, nil).Once()
+	mockRepo.On("SaveBatchWithOutbox", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	// 1. Act: order dikembalikan dengan ID sebelum tersimpan
+	newOrder, err := svc.CreateOrder(ctx, order.CreateOrderRequest{ProductID: testProductID, Quantity: testQuantity})
+	assert.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, newOrder.ID)
+	mockRepo.AssertNotCalled(t, "SaveWithOutbox", mock.Anything, mock.Anything)
+
+	// 2. Assert: setelah di-flush, order tersimpan dan cache produk dihapus
+	writer.Start()
+	writer.Close()
+	mockRepo.AssertExpectations(t)
+	assert.False(t, mr.Exists(getOrdersCacheKey(testProductID)), "Cache order per produk harus dihapus setelah flush")
 }
 
 func TestOrderService_CreateOrder_ProductInfoFails(t *testing.T) {
-	// FIX: Menggunakan '_' untuk mockRepo dan mockPublisher
-	svc, _, _, mr, mockProductClient := setupTest(t)
+	svc, _, mr, mockProductClient := setupTest(t)
 	defer mr.Close()
 
 	// 1. Arrange: Mock Klien Produk GAGAL
 	httpErr := errors.New("product service unavailable")
-	mockProductClient.On("GetProductInfo", testProductID).
+	mockProductClient.On("GetProductInfo", mock.Anything, testProductID).
 		Return(nil, httpErr).Once()
 
 	// 2. Act
 	createReq := order.CreateOrderRequest{ProductID: testProductID, Quantity: testQuantity}
-	_, err := svc.CreateOrder(createReq)
+	_, err := svc.CreateOrder(ctx, createReq)
 
 	// 3. Assert
 	assert.Error(t, err)
@@ -151,54 +305,271 @@ func TestOrderService_CreateOrder_ProductInfoFails(t *testing.T) {
 // --- TEST CASES: GetOrdersByProductID ---
 
 func TestOrderService_GetOrdersByProductID_CacheHit(t *testing.T) {
-	// FIX: Menggunakan '_' untuk mockPublisher dan mockProductClient
-	svc, mockRepo, _, mr, _ := setupTest(t)
+	// FIX: Menggunakan '_' untuk mockProductClient
+	svc, mockRepo, mr, _ := setupTest(t)
 	defer mr.Close()
 
 	// 1. Arrange: Data Order
-	expectedOrders := []order.Order{
+	expectedPage := order.OrderPage{Orders: []order.Order{
 		{ID: uuid.New(), ProductID: testProductID, TotalPrice: 1000},
-	}
-	ordersJSON, _ := json.Marshal(expectedOrders)
+	}}
+	pageJSON, _ := json.Marshal(expectedPage)
 
 	// Pre-populate Redis (Ini adalah cache HIT yang valid, karena cache ini diakses LANGSUNG oleh OrderService)
-	mr.Set(getOrdersCacheKey(testProductID), string(ordersJSON))
-
-	// Pastikan Repository TIDAK dipanggil
-	mockRepo.AssertNotCalled(t, "FindByProductID", mock.Anything)
+	// Field hash = bentuk query yang sudah dinormalisasi (limit default 20)
+	mr.HSet(getOrdersCacheKey(testProductID), "limit=20", string(pageJSON))
 
 	// 2. Act
-	result, err := svc.GetOrdersByProductID(testProductID)
+	result, err := svc.GetOrdersByProductID(ctx, testProductID, order.OrderListQuery{})
 
 	// 3. Assert
 	assert.NoError(t, err)
-	assert.Equal(t, len(expectedOrders), len(result))
+	assert.Equal(t, len(expectedPage.Orders), len(result.Orders))
+
+	// Pastikan Repository TIDAK dipanggil
+	mockRepo.AssertNotCalled(t, "FindByProductID", mock.Anything, mock.Anything)
 }
 
 func TestOrderService_GetOrdersByProductID_CacheMiss(t *testing.T) {
-	// FIX: Menggunakan '_' untuk mockPublisher dan mockProductClient
-	svc, mockRepo, _, mr, _ := setupTest(t)
+	// FIX: Menggunakan '_' untuk mockProductClient
+	svc, mockRepo, mr, _ := setupTest(t)
 	defer mr.Close()
 
 	// 1. Arrange: Redis kosong (CACHE MISS)
 
-	// 2. Arrange: Mock Repository (akan dipanggil)
-	expectedOrders := []order.Order{
+	// 2. Arrange: Mock Repository (akan dipanggil dengan query yang sudah dinormalisasi)
+	expectedPage := &order.OrderPage{Orders: []order.Order{
 		{ID: uuid.New(), ProductID: testProductID, TotalPrice: 1000},
-	}
-	mockRepo.On("FindByProductID", testProductID).
-		Return(expectedOrders, nil).Once()
+	}}
+	mockRepo.On("FindByProductID", mock.Anything, testProductID, order.OrderListQuery{Limit: order.DefaultPageLimit}).
+		Return(expectedPage, nil).Once()
 
 	// 3. Act
-	result, err := svc.GetOrdersByProductID(testProductID)
+	result, err := svc.GetOrdersByProductID(ctx, testProductID, order.OrderListQuery{})
 
 	// 4. Assert
 	assert.NoError(t, err)
-	assert.Equal(t, len(expectedOrders), len(result))
+	assert.Equal(t, len(expectedPage.Orders), len(result.Orders))
 
 	// Verifikasi data sekarang ada di Redis
-	val, _ := mr.Get(getOrdersCacheKey(testProductID))
+	val := mr.HGet(getOrdersCacheKey(testProductID), "limit=20")
 	assert.True(t, len(val) > 0, "Orders must be cached after cache miss")
 
 	mockRepo.AssertExpectations(t)
 }
+
+func TestOrderService_GetOrdersByProductID_CachePerQueryShape(t *testing.T) {
+	svc, mockRepo, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	pendingQuery := order.OrderListQuery{Status: order.StatusPending, Limit: 5}
+	failedQuery := order.OrderListQuery{Status: order.StatusFailed, Limit: 5}
+	mockRepo.On("FindByProductID", mock.Anything, testProductID, pendingQuery).Return(&order.OrderPage{}, nil).Once()
+	mockRepo.On("FindByProductID", mock.Anything, testProductID, failedQuery).Return(&order.OrderPage{}, nil).Once()
+
+	// Query berbeda = field cache berbeda, query sama = cache hit
+	_, err := svc.GetOrdersByProductID(ctx, testProductID, pendingQuery)
+	assert.NoError(t, err)
+	_, err = svc.GetOrdersByProductID(ctx, testProductID, failedQuery)
+	assert.NoError(t, err)
+	_, err = svc.GetOrdersByProductID(ctx, testProductID, pendingQuery)
+	assert.NoError(t, err)
+
+	fields, _ := mr.HKeys(getOrdersCacheKey(testProductID))
+	assert.Len(t, fields, 2)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_GetOrdersByProductID_InvalidQuery(t *testing.T) {
+	svc, mockRepo, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	_, err := svc.GetOrdersByProductID(ctx, testProductID, order.OrderListQuery{Limit: order.MaxPageLimit + 1})
+	assert.ErrorIs(t, err, order.ErrInvalidOrderQuery)
+
+	_, err = svc.GetOrdersByProductID(ctx, testProductID, order.OrderListQuery{Status: "SHIPPED"})
+	assert.ErrorIs(t, err, order.ErrInvalidStatus)
+
+	mockRepo.AssertNotCalled(t, "FindByProductID", mock.Anything, mock.Anything)
+}
+
+// --- TEST CASES: GetOrderByID ---
+
+func getOrderCacheKey(id uuid.UUID) string {
+	return fmt.Sprintf("order:%s", id.String())
+}
+
+func TestOrderService_GetOrderByID_CacheHit(t *testing.T) {
+	svc, mockRepo, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	cachedOrder := order.Order{ID: testOrderID, ProductID: testProductID, TotalPrice: 1000}
+	orderJSON, _ := json.Marshal(cachedOrder)
+	mr.Set(getOrderCacheKey(testOrderID), string(orderJSON))
+
+	result, err := svc.GetOrderByID(ctx, testOrderID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testOrderID, result.ID)
+	mockRepo.AssertNotCalled(t, "FindByID", mock.Anything)
+}
+
+func TestOrderService_GetOrderByID_CacheMiss(t *testing.T) {
+	svc, mockRepo, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	mockRepo.On("FindByID", mock.Anything, testOrderID).
+		Return(&order.Order{ID: testOrderID, ProductID: testProductID}, nil).Once()
+
+	result, err := svc.GetOrderByID(ctx, testOrderID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, testOrderID, result.ID)
+	assert.True(t, mr.Exists(getOrderCacheKey(testOrderID)), "Order harus di-cache setelah cache miss")
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_GetOrderByID_NotFound(t *testing.T) {
+	svc, mockRepo, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	mockRepo.On("FindByID", mock.Anything, testOrderID).Return(nil, order.ErrOrderNotFound).Once()
+
+	_, err := svc.GetOrderByID(ctx, testOrderID)
+
+	assert.ErrorIs(t, err, order.ErrOrderNotFound)
+	assert.False(t, mr.Exists(getOrderCacheKey(testOrderID)), "Order yang tidak ditemukan tidak boleh di-cache")
+}
+
+// --- TEST CASES: UpdateOrderStatus ---
+
+func TestOrderService_UpdateOrderStatus_Success(t *testing.T) {
+	svc, mockRepo, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	updatedOrder := &order.Order{ID: testOrderID, ProductID: testProductID, Status: order.StatusProcessed}
+	mr.Set(getOrdersCacheKey(testProductID), "[]")
+	mr.Set(getOrderCacheKey(testOrderID), "{}")
+
+	mockRepo.On("UpdateStatus", mock.Anything, testOrderID, order.StatusProcessed).
+		Return(updatedOrder, order.StatusPending, nil).Once()
+
+	result, err := svc.UpdateOrderStatus(ctx, testOrderID, order.StatusProcessed)
+
+	assert.NoError(t, err)
+	assert.Equal(t, order.StatusProcessed, result.Status)
+	assert.False(t, mr.Exists(getOrdersCacheKey(testProductID)), "Cache order per produk harus dihapus")
+	assert.False(t, mr.Exists(getOrderCacheKey(testOrderID)), "Cache order tunggal harus dihapus")
+
+	// Event ditulis ke outbox bersama perubahan status, bukan di-publish langsung
+	if assert.Len(t, mockRepo.StatusEvents, 1) {
+		event := mockRepo.StatusEvents[0]
+		assert.Equal(t, "orders_exchange", event.Exchange)
+		assert.Equal(t, "order.status_changed", event.RoutingKey)
+		assert.Equal(t, testOrderID, event.AggregateID)
+
+		var body map[string]string
+		assert.NoError(t, json.Unmarshal(event.Payload, &body))
+		assert.Equal(t, string(order.StatusPending), body["previousStatus"])
+		assert.Equal(t, string(order.StatusProcessed), body["status"])
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_UpdateOrderStatus_RejectedTransition(t *testing.T) {
+	svc, mockRepo, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	mockRepo.On("UpdateStatus", mock.Anything, testOrderID, order.StatusPending).
+		Return(nil, order.OrderStatus(""), order.ErrInvalidStatusTransition).Once()
+
+	_, err := svc.UpdateOrderStatus(ctx, testOrderID, order.StatusPending)
+
+	assert.ErrorIs(t, err, order.ErrInvalidStatusTransition)
+	assert.Empty(t, mockRepo.StatusEvents)
+}
+
+func TestOrderService_UpdateOrderStatus_UnknownStatus(t *testing.T) {
+	svc, mockRepo, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	_, err := svc.UpdateOrderStatus(ctx, testOrderID, order.OrderStatus("SHIPPED"))
+
+	assert.ErrorIs(t, err, order.ErrInvalidStatus)
+	mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything)
+}
+
+// --- TEST CASES: FinalizeOrder ---
+
+func TestOrderService_FinalizeOrder_PublishesFinalEvent(t *testing.T) {
+	svc, mockRepo, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	failedOrder := &order.Order{ID: testOrderID, ProductID: testProductID, Status: order.StatusFailed}
+	mr.HSet(getOrdersCacheKey(testProductID), "limit=20", "{}")
+	mr.Set(getOrderCacheKey(testOrderID), "{}")
+
+	mockRepo.On("UpdateStatus", mock.Anything, testOrderID, order.StatusFailed).
+		Return(failedOrder, order.StatusPending, nil).Once()
+
+	result, err := svc.FinalizeOrder(ctx, testOrderID, order.StatusFailed, "stok habis")
+
+	assert.NoError(t, err)
+	assert.Equal(t, order.StatusFailed, result.Status)
+	assert.False(t, mr.Exists(getOrdersCacheKey(testProductID)), "Cache order per produk harus dihapus")
+	assert.False(t, mr.Exists(getOrderCacheKey(testOrderID)), "Cache order tunggal harus dihapus")
+
+	if assert.Len(t, mockRepo.StatusEvents, 1) {
+		event := mockRepo.StatusEvents[0]
+		assert.Equal(t, "orders_exchange", event.Exchange)
+		assert.Equal(t, OrderFailedRoutingKey, event.RoutingKey)
+
+		var body map[string]interface{}
+		assert.NoError(t, json.Unmarshal(event.Payload, &body))
+		assert.Equal(t, string(order.StatusFailed), body["status"])
+		assert.Equal(t, "stok habis", body["reason"])
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func TestOrderService_FinalizeOrder_RejectsNonTerminalStatus(t *testing.T) {
+	svc, mockRepo, mr, _ := setupTest(t)
+	defer mr.Close()
+
+	_, err := svc.FinalizeOrder(ctx, testOrderID, order.StatusPending, "")
+
+	assert.ErrorIs(t, err, order.ErrInvalidStatus)
+	mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// --- TEST CASES: Opsi konfigurasi ---
+
+func TestOrderService_CacheTTLAndExchangeOptions(t *testing.T) {
+	mockRepo := new(repository.MockOrderRepository)
+	mr, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("Failed to start miniredis: %v", err)
+	}
+	defer mr.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+
+	svc := NewOrderService(mockRepo, rdb, new(MockProductService),
+		WithCacheTTL(time.Minute), WithOrdersExchange("orders_v2"))
+
+	// 1. Cache order memakai TTL dari opsi, bukan DefaultOrderCacheTTL
+	mockRepo.On("FindByID", mock.Anything, testOrderID).
+		Return(&order.Order{ID: testOrderID, ProductID: testProductID}, nil).Once()
+	_, err = svc.GetOrderByID(ctx, testOrderID)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, mr.TTL(getOrderCacheKey(testOrderID)))
+
+	// 2. Event dikirim ke exchange dari opsi
+	mockRepo.On("UpdateStatus", mock.Anything, testOrderID, order.StatusProcessed).
+		Return(&order.Order{ID: testOrderID, ProductID: testProductID, Status: order.StatusProcessed}, order.StatusPending, nil).Once()
+	_, err = svc.UpdateOrderStatus(ctx, testOrderID, order.StatusProcessed)
+	assert.NoError(t, err)
+	if assert.Len(t, mockRepo.StatusEvents, 1) {
+		assert.Equal(t, "orders_v2", mockRepo.StatusEvents[0].Exchange)
+	}
+}
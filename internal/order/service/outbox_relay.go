@@ -0,0 +1,223 @@
+package service
+
+import (
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/metrics"
+	"challenge-order-service/internal/order"
+	"challenge-order-service/internal/order/repository"
+	"challenge-order-service/internal/tracing"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// DefaultOutboxMaxAttempts adalah batas percobaan publish sebelum event ditandai FAILED
+	DefaultOutboxMaxAttempts = 20
+	// DefaultOutboxRetention adalah masa simpan event SENT sebelum dihapus
+	DefaultOutboxRetention = 7 * 24 * time.Hour
+)
+
+// OutboxRelay mem-publish baris 'outbox_events' yang masih PENDING lewat Publisher.
+// Setiap batch diklaim (ClaimPending) sehingga relay di beberapa replika tidak
+// mem-publish event yang sama. Pengiriman tetap at-least-once: jika MarkSent gagal
+// setelah publish berhasil, event dikirim ulang setelah klaimnya habis, jadi
+// consumer harus idempoten terhadap orderId.
+//
+// Event yang gagal dikirim maxAttempts kali ditandai FAILED (dicatat di log dan
+// metrik order_service_outbox_events_failed_total) dan tidak dicoba lagi. Event
+// SENT yang lebih tua dari retention dihapus berkala oleh Run.
+type OutboxRelay struct {
+	repo            repository.OutboxRepository
+	publisher       Publisher
+	batchSize       int
+	claimLease      time.Duration // lama event yang diklaim tidak diambil relay lain
+	pollInterval    time.Duration
+	baseBackoff     time.Duration
+	maxBackoff      time.Duration
+	maxAttempts     int
+	retention       time.Duration // 0 = event SENT tidak pernah dihapus
+	cleanupInterval time.Duration
+	cleanupBatch    int
+	now             func() time.Time
+	logger          *slog.Logger
+}
+
+// NewOutboxRelay membuat relay dengan konfigurasi default
+func NewOutboxRelay(repo repository.OutboxRepository, publisher Publisher) *OutboxRelay {
+	return &OutboxRelay{
+		repo:         repo,
+		publisher:    publisher,
+		batchSize:    100,
+		claimLease:   2 * time.Minute,
+		pollInterval: 1 * time.Second,
+		baseBackoff:  1 * time.Second,
+		maxBackoff:   5 * time.Minute,
+		maxAttempts:  DefaultOutboxMaxAttempts,
+		retention:    DefaultOutboxRetention,
+		// Pembersihan cukup jarang; setiap putaran menghapus per batch
+		cleanupInterval: time.Hour,
+		cleanupBatch:    1000,
+		now:             time.Now,
+		logger:          slog.Default(),
+	}
+}
+
+// SetLogger mengganti logger relay (default slog.Default())
+func (r *OutboxRelay) SetLogger(logger *slog.Logger) {
+	r.logger = logger
+}
+
+// SetMaxAttempts mengganti batas percobaan publish (default DefaultOutboxMaxAttempts); <= 0 diabaikan
+func (r *OutboxRelay) SetMaxAttempts(n int) {
+	if n > 0 {
+		r.maxAttempts = n
+	}
+}
+
+// SetRetention mengganti masa simpan event SENT (default DefaultOutboxRetention); 0 = tidak dihapus
+func (r *OutboxRelay) SetRetention(retention time.Duration) {
+	r.retention = retention
+}
+
+// Run menjalankan loop polling sampai ctx dibatalkan
+func (r *OutboxRelay) Run(ctx context.Context) {
+	r.logger.InfoContext(ctx, "outbox relay dimulai")
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	var lastCleanup time.Time
+	for {
+		if err := r.Flush(ctx); err != nil {
+			r.logger.ErrorContext(ctx, "outbox relay gagal memproses event pending", logging.Err(err))
+		}
+		if r.retention > 0 && r.now().Sub(lastCleanup) >= r.cleanupInterval {
+			lastCleanup = r.now()
+			if _, err := r.Cleanup(ctx); err != nil {
+				r.logger.ErrorContext(ctx, "outbox relay gagal menghapus event lama", logging.Err(err))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			r.logger.Info("outbox relay berhenti")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush menguras semua batch yang penuh; dipanggil setiap tick dan sekali lagi saat shutdown.
+// Berhenti pada error pertama agar gangguan database tidak membuat loop berputar terus.
+func (r *OutboxRelay) Flush(ctx context.Context) error {
+	for {
+		n, err := r.ProcessPending(ctx)
+		if err != nil {
+			return err
+		}
+		if n < r.batchSize {
+			return nil
+		}
+	}
+}
+
+// ProcessPending mem-publish satu batch event dan mengembalikan jumlah event yang diproses.
+// Jika status event gagal dicatat, sisa batch ditinggalkan; klaimnya habis setelah
+// claimLease lalu event diambil ulang.
+func (r *OutboxRelay) ProcessPending(ctx context.Context) (int, error) {
+	now := r.now()
+	events, err := r.repo.ClaimPending(ctx, r.batchSize, now, now.Add(r.claimLease))
+	if err != nil {
+		return 0, err
+	}
+
+	for i, event := range events {
+		if err := r.publisher.Publish(eventContext(ctx, event), event.Exchange, event.RoutingKey, event.Payload); err != nil {
+			attempts := event.Attempts + 1
+			if attempts >= r.maxAttempts {
+				// Batas percobaan habis: hentikan retry dan minta perhatian operator
+				r.logger.ErrorContext(ctx, "outbox event gagal dikirim setelah batas percobaan, ditandai FAILED",
+					slog.String("event_id", event.ID.String()), slog.String("routing_key", event.RoutingKey),
+					slog.Int("attempt", attempts), logging.Err(err))
+				if err := r.repo.MarkDead(ctx, event.ID, attempts, err.Error()); err != nil {
+					return i, fmt.Errorf("gagal menandai outbox event %s sebagai FAILED: %w", event.ID, err)
+				}
+				metrics.OutboxEventsFailed.WithLabelValues(event.RoutingKey).Inc()
+				continue
+			}
+
+			next := r.now().Add(r.backoff(attempts))
+			r.logger.WarnContext(ctx, "gagal publish outbox event",
+				slog.String("event_id", event.ID.String()), slog.String("routing_key", event.RoutingKey),
+				slog.Int("attempt", attempts), slog.Time("next_attempt_at", next), logging.Err(err))
+			if err := r.repo.MarkFailed(ctx, event.ID, attempts, next, err.Error()); err != nil {
+				return i, fmt.Errorf("gagal mencatat kegagalan outbox event %s: %w", event.ID, err)
+			}
+			continue
+		}
+
+		if err := r.repo.MarkSent(ctx, event.ID, r.now()); err != nil {
+			return i, fmt.Errorf("gagal menandai outbox event %s sebagai SENT: %w", event.ID, err)
+		}
+	}
+	return len(events), nil
+}
+
+// Cleanup menghapus event SENT yang lebih tua dari retention (per batch) dan
+// mengembalikan jumlah event yang dihapus
+func (r *OutboxRelay) Cleanup(ctx context.Context) (int64, error) {
+	if r.retention <= 0 {
+		return 0, nil
+	}
+
+	before := r.now().Add(-r.retention)
+	var total int64
+	for {
+		n, err := r.repo.DeleteSentBefore(ctx, before, r.cleanupBatch)
+		total += n
+		metrics.OutboxEventsDeleted.Add(float64(n))
+		if err != nil {
+			return total, err
+		}
+		if n < int64(r.cleanupBatch) {
+			break
+		}
+	}
+	if total > 0 {
+		r.logger.InfoContext(ctx, "event outbox lama dihapus", slog.Int64("deleted", total))
+	}
+	return total, nil
+}
+
+// backoff menghitung jeda eksponensial (base * 2^(attempts-1)) dengan batas maxBackoff
+func (r *OutboxRelay) backoff(attempts int) time.Duration {
+	delay := r.baseBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= r.maxBackoff {
+			return r.maxBackoff
+		}
+	}
+	return delay
+}
+
+// outboxHeaders mengambil trace context dan request ID dari ctx untuk disimpan
+// di OutboxEvent.Headers saat event ditulis
+func outboxHeaders(ctx context.Context) map[string]string {
+	headers := tracing.InjectMap(ctx)
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		headers[logging.RequestIDHeader] = requestID
+	}
+	return headers
+}
+
+// eventContext memulihkan header dari outboxHeaders ke ctx publish, sehingga
+// span publish tersambung ke trace request asal dan X-Request-ID ikut terkirim
+func eventContext(ctx context.Context, event order.OutboxEvent) context.Context {
+	if requestID := event.Headers[logging.RequestIDHeader]; requestID != "" {
+		ctx = logging.WithRequestID(ctx, requestID)
+	}
+	return tracing.ExtractMap(ctx, event.Headers)
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/metrics"
+	"challenge-order-service/internal/order"
+	"challenge-order-service/internal/order/repository"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setupRelayTest() (*OutboxRelay, *repository.MockOutboxRepository, *MockPublisher, time.Time) {
+	mockRepo := new(repository.MockOutboxRepository)
+	mockPublisher := new(MockPublisher)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	relay := NewOutboxRelay(mockRepo, mockPublisher)
+	relay.now = func() time.Time { return now }
+	return relay, mockRepo, mockPublisher, now
+}
+
+func TestOutboxRelay_ProcessPending_PublishesAndMarksSent(t *testing.T) {
+	relay, mockRepo, mockPublisher, now := setupRelayTest()
+
+	event := order.OutboxEvent{ID: uuid.New(), Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`)}
+	mockRepo.On("ClaimPending", mock.Anything, 100, now, now.Add(2*time.Minute)).Return([]order.OutboxEvent{event}, nil).Once()
+	mockPublisher.On("Publish", mock.Anything, "orders_exchange", "order.created", event.Payload).Return(nil).Once()
+	mockRepo.On("MarkSent", mock.Anything, event.ID, now).Return(nil).Once()
+
+	n, err := relay.ProcessPending(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	mockRepo.AssertExpectations(t)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestOutboxRelay_ProcessPending_RestoresRequestContext(t *testing.T) {
+	previous := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(previous) })
+
+	// Header yang ditulis saat POST /orders (lihat outboxHeaders)
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	requestCtx := trace.ContextWithSpanContext(logging.WithRequestID(ctx, "req-123"),
+		trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID, TraceFlags: trace.FlagsSampled}))
+	headers := outboxHeaders(requestCtx)
+	assert.Equal(t, "req-123", headers[logging.RequestIDHeader])
+
+	relay, mockRepo, mockPublisher, now := setupRelayTest()
+	event := order.OutboxEvent{ID: uuid.New(), Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`), Headers: headers}
+	mockRepo.On("ClaimPending", mock.Anything, 100, now, now.Add(2*time.Minute)).Return([]order.OutboxEvent{event}, nil).Once()
+	mockPublisher.On("Publish", mock.MatchedBy(func(publishCtx context.Context) bool {
+		return logging.RequestID(publishCtx) == "req-123" &&
+			trace.SpanContextFromContext(publishCtx).TraceID() == traceID
+	}), "orders_exchange", "order.created", event.Payload).Return(nil).Once()
+	mockRepo.On("MarkSent", mock.Anything, event.ID, now).Return(nil).Once()
+
+	_, err := relay.ProcessPending(ctx)
+
+	assert.NoError(t, err)
+	mockPublisher.AssertExpectations(t)
+}
+
+func TestOutboxRelay_ProcessPending_SchedulesRetryWithBackoff(t *testing.T) {
+	relay, mockRepo, mockPublisher, now := setupRelayTest()
+
+	// Percobaan ketiga: jeda = 1s * 2^2 = 4s
+	event := order.OutboxEvent{ID: uuid.New(), Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`), Attempts: 2}
+	mockRepo.On("ClaimPending", mock.Anything, 100, now, now.Add(2*time.Minute)).Return([]order.OutboxEvent{event}, nil).Once()
+	mockPublisher.On("Publish", mock.Anything, "orders_exchange", "order.created", event.Payload).Return(errors.New("broker down")).Once()
+	mockRepo.On("MarkFailed", mock.Anything, event.ID, 3, now.Add(4*time.Second), "broker down").Return(nil).Once()
+
+	_, err := relay.ProcessPending(ctx)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "MarkSent", mock.Anything, mock.Anything)
+}
+
+func TestOutboxRelay_ProcessPending_MarksFailedAfterMaxAttempts(t *testing.T) {
+	relay, mockRepo, mockPublisher, now := setupRelayTest()
+	relay.SetMaxAttempts(3)
+
+	// Percobaan ketiga dari maksimal tiga: event berhenti dikirim ulang
+	event := order.OutboxEvent{ID: uuid.New(), Exchange: "orders_exchange", RoutingKey: "order.finalized", Payload: []byte(`{}`), Attempts: 2}
+	mockRepo.On("ClaimPending", mock.Anything, 100, now, now.Add(2*time.Minute)).Return([]order.OutboxEvent{event}, nil).Once()
+	mockPublisher.On("Publish", mock.Anything, "orders_exchange", "order.finalized", event.Payload).Return(errors.New("unroutable")).Once()
+	mockRepo.On("MarkDead", mock.Anything, event.ID, 3, "unroutable").Return(nil).Once()
+	before := testutil.ToFloat64(metrics.OutboxEventsFailed.WithLabelValues("order.finalized"))
+
+	_, err := relay.ProcessPending(ctx)
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "MarkFailed", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.Equal(t, before+1, testutil.ToFloat64(metrics.OutboxEventsFailed.WithLabelValues("order.finalized")))
+}
+
+func TestOutboxRelay_Cleanup_DeletesSentEventsInBatches(t *testing.T) {
+	relay, mockRepo, _, now := setupRelayTest()
+	relay.cleanupBatch = 2
+	relay.SetRetention(24 * time.Hour)
+
+	// Batch penuh diulang sampai sisa baris kurang dari satu batch
+	cutoff := now.Add(-24 * time.Hour)
+	mockRepo.On("DeleteSentBefore", mock.Anything, cutoff, 2).Return(int64(2), nil).Once()
+	mockRepo.On("DeleteSentBefore", mock.Anything, cutoff, 2).Return(int64(1), nil).Once()
+
+	deleted, err := relay.Cleanup(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), deleted)
+	mockRepo.AssertExpectations(t)
+
+	// Retensi 0 menonaktifkan pembersihan
+	relay.SetRetention(0)
+	deleted, err = relay.Cleanup(ctx)
+	assert.NoError(t, err)
+	assert.Zero(t, deleted)
+	mockRepo.AssertNumberOfCalls(t, "DeleteSentBefore", 2)
+}
+
+func TestOutboxRelay_Flush_StopsWhenMarkSentFails(t *testing.T) {
+	relay, mockRepo, mockPublisher, now := setupRelayTest()
+	relay.batchSize = 2
+
+	// Batch penuh, tapi database gagal: Flush harus berhenti, bukan mengambil batch lagi
+	first := order.OutboxEvent{ID: uuid.New(), Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`)}
+	second := order.OutboxEvent{ID: uuid.New(), Exchange: "orders_exchange", RoutingKey: "order.created", Payload: []byte(`{}`)}
+	mockRepo.On("ClaimPending", mock.Anything, 2, now, now.Add(2*time.Minute)).
+		Return([]order.OutboxEvent{first, second}, nil).Once()
+	mockPublisher.On("Publish", mock.Anything, "orders_exchange", "order.created", first.Payload).Return(nil).Once()
+	mockRepo.On("MarkSent", mock.Anything, first.ID, now).Return(errors.New("db down")).Once()
+
+	err := relay.Flush(ctx)
+
+	assert.ErrorContains(t, err, "db down")
+	mockRepo.AssertNumberOfCalls(t, "ClaimPending", 1)
+	mockPublisher.AssertNumberOfCalls(t, "Publish", 1)
+}
+
+func TestOutboxRelay_Backoff_IsCapped(t *testing.T) {
+	relay, _, _, _ := setupRelayTest()
+
+	assert.Equal(t, 1*time.Second, relay.backoff(1))
+	assert.Equal(t, 2*time.Second, relay.backoff(2))
+	assert.Equal(t, 5*time.Minute, relay.backoff(50))
+}
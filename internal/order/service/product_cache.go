@@ -0,0 +1,126 @@
+package service
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductCacheStats adalah ringkasan pemakaian cache info produk
+type ProductCacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Size   int    `json:"size"`
+}
+
+// productCacheEntry adalah satu elemen di daftar LRU
+type productCacheEntry struct {
+	productID uuid.UUID
+	product   ProductResponse
+	expiresAt time.Time
+}
+
+// productCache adalah cache in-memory berkapasitas tetap dengan TTL per entri.
+// Jika penuh, entri yang paling lama tidak dipakai (LRU) dibuang.
+type productCache struct {
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu      sync.Mutex
+	entries map[uuid.UUID]*list.Element
+	lru     *list.List // depan = paling baru dipakai
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func newProductCache(capacity int, ttl time.Duration) *productCache {
+	return &productCache{
+		capacity: capacity,
+		ttl:      ttl,
+		now:      time.Now,
+		entries:  make(map[uuid.UUID]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Get mengembalikan salinan produk; entri yang kedaluwarsa dihapus dan dihitung miss
+func (c *productCache) Get(productID uuid.UUID) (*ProductResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[productID]
+	if !ok {
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	entry := elem.Value.(*productCacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.removeElement(elem)
+		c.misses.Add(1)
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+	c.hits.Add(1)
+	product := entry.product
+	return &product, true
+}
+
+// Set menyimpan (salinan) produk dan membuang entri LRU jika kapasitas terlampaui
+func (c *productCache) Set(productID uuid.UUID, product *ProductResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := c.now().Add(c.ttl)
+	if elem, ok := c.entries[productID]; ok {
+		entry := elem.Value.(*productCacheEntry)
+		entry.product = *product
+		entry.expiresAt = expiresAt
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[productID] = c.lru.PushFront(&productCacheEntry{
+		productID: productID,
+		product:   *product,
+		expiresAt: expiresAt,
+	})
+	for c.lru.Len() > c.capacity {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// Delete menghapus entri produk (tidak masalah jika tidak ada)
+func (c *productCache) Delete(productID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[productID]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Stats mengembalikan jumlah hit/miss dan ukuran cache saat ini
+func (c *productCache) Stats() ProductCacheStats {
+	c.mu.Lock()
+	size := c.lru.Len()
+	c.mu.Unlock()
+
+	return ProductCacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Size:   size,
+	}
+}
+
+// removeElement harus dipanggil dengan c.mu terkunci
+func (c *productCache) removeElement(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*productCacheEntry).productID)
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProductCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newProductCache(2, time.Hour)
+	first, second, third := uuid.New(), uuid.New(), uuid.New()
+
+	cache.Set(first, &ProductResponse{ID: first})
+	cache.Set(second, &ProductResponse{ID: second})
+
+	// 'first' baru dipakai, jadi 'second' yang dibuang saat 'third' masuk
+	_, ok := cache.Get(first)
+	assert.True(t, ok)
+	cache.Set(third, &ProductResponse{ID: third})
+
+	_, ok = cache.Get(second)
+	assert.False(t, ok)
+	_, ok = cache.Get(first)
+	assert.True(t, ok)
+	_, ok = cache.Get(third)
+	assert.True(t, ok)
+
+	assert.Equal(t, ProductCacheStats{Hits: 3, Misses: 1, Size: 2}, cache.Stats())
+}
+
+func TestProductCache_ExpiresEntriesAfterTTL(t *testing.T) {
+	now := time.Now()
+	cache := newProductCache(10, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	productID := uuid.New()
+	cache.Set(productID, &ProductResponse{ID: productID, Qty: 5})
+
+	product, ok := cache.Get(productID)
+	assert.True(t, ok)
+	assert.Equal(t, 5, product.Qty)
+
+	now = now.Add(time.Minute)
+	_, ok = cache.Get(productID)
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Stats().Size)
+}
+
+func TestProductCache_ReturnsCopies(t *testing.T) {
+	cache := newProductCache(10, time.Hour)
+	productID := uuid.New()
+	cache.Set(productID, &ProductResponse{ID: productID, Qty: 5})
+
+	product, _ := cache.Get(productID)
+	product.Qty = 0
+
+	cached, _ := cache.Get(productID)
+	assert.Equal(t, 5, cached.Qty)
+}
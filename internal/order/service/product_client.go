@@ -0,0 +1,269 @@
+package service
+
+import (
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/metrics"
+	"challenge-order-service/internal/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrProductServiceUnavailable dikembalikan saat product-service gagal dihubungi
+// setelah semua retry habis, atau saat circuit breaker sedang terbuka.
+// errors.Is(err, ErrUpstreamUnavailable) juga bernilai true.
+var ErrProductServiceUnavailable error = &upstreamError{service: "product-service"}
+
+// ProductClientConfig mengatur alamat, timeout, retry dan circuit breaker ProductClientImpl
+type ProductClientConfig struct {
+	BaseURL                 string        // mis. http://product-service:3000 (PRODUCT_SERVICE_URL)
+	Timeout                 time.Duration // batas waktu per percobaan HTTP
+	MaxRetries              int           // jumlah retry setelah percobaan pertama (5xx / error jaringan)
+	RetryBaseDelay          time.Duration // jeda awal backoff, digandakan tiap retry
+	RetryMaxDelay           time.Duration // batas atas jeda backoff
+	BreakerFailureThreshold int           // kegagalan berturut-turut sebelum sirkuit dibuka
+	BreakerOpenTimeout      time.Duration // lama sirkuit terbuka sebelum request percobaan
+	CacheSize               int           // jumlah produk maksimal di cache in-memory (LRU)
+	CacheTTL                time.Duration // umur maksimal info produk di cache
+	Logger                  *slog.Logger  // nil = slog.Default()
+	CacheLogger             *slog.Logger  // untuk baris CACHE HIT/MISS; nil = Logger
+}
+
+// DefaultProductClientConfig adalah konfigurasi yang dipakai untuk nilai yang kosong
+func DefaultProductClientConfig() ProductClientConfig {
+	return ProductClientConfig{
+		BaseURL:                 "http://product-service:3000",
+		Timeout:                 2 * time.Second,
+		MaxRetries:              2,
+		RetryBaseDelay:          100 * time.Millisecond,
+		RetryMaxDelay:           time.Second,
+		BreakerFailureThreshold: 5,
+		BreakerOpenTimeout:      30 * time.Second,
+		CacheSize:               10000,
+		CacheTTL:                30 * time.Second,
+	}
+}
+
+// ProductClientImpl memanggil product-service lewat HTTP dengan cache in-memory
+// (LRU + TTL), retry ber-jitter dan circuit breaker.
+type ProductClientImpl struct {
+	cfg        ProductClientConfig
+	httpClient *http.Client
+	breaker    *circuitBreaker
+	cache      *productCache
+}
+
+// NewProductClientImpl membuat client dari konfigurasi; nilai kosong diisi default
+func NewProductClientImpl(cfg ProductClientConfig) *ProductClientImpl {
+	def := DefaultProductClientConfig()
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = def.BaseURL
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = def.Timeout
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	}
+	if cfg.RetryBaseDelay <= 0 {
+		cfg.RetryBaseDelay = def.RetryBaseDelay
+	}
+	if cfg.RetryMaxDelay <= 0 {
+		cfg.RetryMaxDelay = def.RetryMaxDelay
+	}
+	if cfg.BreakerFailureThreshold <= 0 {
+		cfg.BreakerFailureThreshold = def.BreakerFailureThreshold
+	}
+	if cfg.BreakerOpenTimeout <= 0 {
+		cfg.BreakerOpenTimeout = def.BreakerOpenTimeout
+	}
+	if cfg.CacheSize <= 0 {
+		cfg.CacheSize = def.CacheSize
+	}
+	if cfg.CacheTTL <= 0 {
+		cfg.CacheTTL = def.CacheTTL
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	if cfg.CacheLogger == nil {
+		cfg.CacheLogger = cfg.Logger
+	}
+
+	return &ProductClientImpl{
+		cfg: cfg,
+		// Timeout per percobaan diatur lewat context, jadi client tidak memakai Timeout global
+		// Transport otelhttp membuat span per percobaan dan mengirim header traceparent
+		httpClient: &http.Client{Transport: otelhttp.NewTransport(http.DefaultTransport)},
+		breaker:    newCircuitBreaker(cfg.BreakerFailureThreshold, cfg.BreakerOpenTimeout),
+		cache:      newProductCache(cfg.CacheSize, cfg.CacheTTL),
+	}
+}
+
+// CircuitState mengembalikan kondisi circuit breaker product-service
+func (c *ProductClientImpl) CircuitState() CircuitState {
+	return c.breaker.State()
+}
+
+// CacheStats mengembalikan jumlah hit/miss dan ukuran cache info produk
+func (c *ProductClientImpl) CacheStats() ProductCacheStats {
+	return c.cache.Stats()
+}
+
+// GetProductInfo membaca dari cache in-memory, lalu fallback ke product-service
+func (c *ProductClientImpl) GetProductInfo(ctx context.Context, productID uuid.UUID) (*ProductResponse, error) {
+
+	// 1. Coba Cache Read (entri kedaluwarsa dianggap miss)
+	if product, found := c.cache.Get(productID); found {
+		c.cfg.CacheLogger.DebugContext(ctx, "CACHE HIT", slog.String("cache", metrics.CacheProductInfo), logging.ProductID(productID))
+		metrics.CacheHit(metrics.CacheProductInfo)
+		return product, nil
+	}
+
+	// 2. HTTP Fallback (Cache Miss)
+	c.cfg.CacheLogger.DebugContext(ctx, "CACHE MISS", slog.String("cache", metrics.CacheProductInfo), logging.ProductID(productID))
+	metrics.CacheMiss(metrics.CacheProductInfo)
+	return c.GetFreshProductInfo(ctx, productID)
+}
+
+// GetFreshProductInfo selalu mengambil dari product-service lalu memperbarui cache
+func (c *ProductClientImpl) GetFreshProductInfo(ctx context.Context, productID uuid.UUID) (*ProductResponse, error) {
+	// Satu span mencakup semua retry; tiap percobaan HTTP menjadi span anak
+	ctx, span := tracing.Tracer().Start(ctx, "ProductClient.GetFreshProductInfo",
+		trace.WithAttributes(attribute.String("product.id", productID.String())))
+	defer span.End()
+
+	newProduct, err := c.fetchProduct(ctx, productID)
+	if err != nil {
+		tracing.SetError(span, err)
+		return nil, err
+	}
+
+	c.cache.Set(productID, newProduct)
+	return newProduct, nil
+}
+
+// fetchProduct memanggil product-service dengan retry dan circuit breaker
+func (c *ProductClientImpl) fetchProduct(ctx context.Context, productID uuid.UUID) (*ProductResponse, error) {
+	// 1. Sirkuit terbuka: gagal cepat tanpa menghubungi product-service
+	if !c.breaker.Allow() {
+		return nil, fmt.Errorf("%w: circuit breaker terbuka", ErrProductServiceUnavailable)
+	}
+
+	productServiceURL := fmt.Sprintf("%s/products/%s", c.cfg.BaseURL, productID.String())
+
+	var lastErr error
+	for attempt := 0; attempt <= c.cfg.MaxRetries; attempt++ {
+		// 2. Jeda backoff sebelum retry, berhenti jika request dibatalkan
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				c.breaker.Abort()
+				return nil, ctx.Err()
+			case <-time.After(c.retryDelay(attempt)):
+			}
+		}
+
+		start := time.Now()
+		product, retryable, err := c.doRequest(ctx, productServiceURL)
+		metrics.UpstreamRequestDuration.WithLabelValues("product-service", upstreamResult(err, retryable)).
+			Observe(time.Since(start).Seconds())
+		if err == nil {
+			c.breaker.Success()
+			return product, nil
+		}
+		if ctx.Err() != nil {
+			// Dibatalkan oleh pemanggil, bukan kesalahan product-service
+			c.breaker.Abort()
+			return nil, ctx.Err()
+		}
+		if !retryable {
+			// product-service merespons (mis. 404), jadi upstream dianggap sehat
+			c.breaker.Success()
+			return nil, err
+		}
+
+		lastErr = err
+		c.cfg.Logger.WarnContext(ctx, "percobaan ke product-service gagal",
+			logging.ProductID(productID), slog.Int("attempt", attempt+1), logging.Err(err))
+	}
+
+	// 3. Semua percobaan gagal
+	c.breaker.Failure()
+	return nil, fmt.Errorf("%w: %v", ErrProductServiceUnavailable, lastErr)
+}
+
+// doRequest menjalankan satu percobaan HTTP. retryable bernilai true untuk
+// error jaringan dan respons 5xx.
+func (c *ProductClientImpl) doRequest(ctx context.Context, url string) (product *ProductResponse, retryable bool, err error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, c.cfg.Timeout)
+	defer cancel()
+
+	// Request mengikuti ctx sehingga dibatalkan jika klien HTTP kita memutus koneksi
+	req, err := http.NewRequestWithContext(attemptCtx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, true, fmt.Errorf("gagal menghubungi product-service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, true, fmt.Errorf("product-service mengembalikan error %d", resp.StatusCode)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false, ErrProductNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("product-service mengembalikan error %d", resp.StatusCode)
+	}
+
+	var newProduct ProductResponse
+	if err := json.NewDecoder(resp.Body).Decode(&newProduct); err != nil {
+		return nil, false, fmt.Errorf("gagal decode respons product-service: %w", err)
+	}
+	return &newProduct, false, nil
+}
+
+// upstreamResult adalah label "result" untuk satu percobaan HTTP
+func upstreamResult(err error, retryable bool) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, ErrProductNotFound):
+		return "not_found"
+	case retryable:
+		return "retryable_error"
+	default:
+		return "error"
+	}
+}
+
+// retryDelay = min(base·2^(attempt-1), max), diacak antara setengah dan penuh
+// agar retry dari banyak request tidak datang bersamaan.
+func (c *ProductClientImpl) retryDelay(attempt int) time.Duration {
+	delay := c.cfg.RetryBaseDelay
+	for i := 1; i < attempt && delay < c.cfg.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > c.cfg.RetryMaxDelay {
+		delay = c.cfg.RetryMaxDelay
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestProductClient membuat client dengan jeda retry sangat kecil agar test cepat
+func newTestProductClient(baseURL string) *ProductClientImpl {
+	return NewProductClientImpl(ProductClientConfig{
+		BaseURL:                 baseURL,
+		Timeout:                 time.Second,
+		MaxRetries:              2,
+		RetryBaseDelay:          time.Millisecond,
+		RetryMaxDelay:           2 * time.Millisecond,
+		BreakerFailureThreshold: 2,
+		BreakerOpenTimeout:      time.Hour,
+	})
+}
+
+func TestProductClient_RetriesOn5xxThenSucceeds(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": testProductID, "name": "Laptop", "price": "100", "qty": 5})
+	}))
+	defer server.Close()
+
+	client := newTestProductClient(server.URL + "/")
+	product, err := client.GetProductInfo(ctx, testProductID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Laptop", product.Name)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	assert.Equal(t, CircuitClosed, client.CircuitState())
+}
+
+func TestProductClient_DoesNotRetryOn4xx(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := newTestProductClient(server.URL)
+	_, err := client.GetProductInfo(ctx, testProductID)
+
+	assert.ErrorIs(t, err, ErrProductNotFound)
+	assert.NotErrorIs(t, err, ErrProductServiceUnavailable)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestProductClient_CircuitOpensAfterRepeatedFailures(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := newTestProductClient(server.URL)
+
+	// Dua panggilan gagal (masing-masing 1 percobaan + 2 retry) membuka sirkuit
+	for i := 0; i < 2; i++ {
+		_, err := client.GetProductInfo(ctx, uuid.New())
+		assert.ErrorIs(t, err, ErrProductServiceUnavailable)
+	}
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+	assert.Equal(t, CircuitOpen, client.CircuitState())
+
+	// Sirkuit terbuka: gagal cepat tanpa request HTTP
+	_, err := client.GetProductInfo(ctx, uuid.New())
+	assert.ErrorIs(t, err, ErrProductServiceUnavailable)
+	assert.ErrorIs(t, err, ErrUpstreamUnavailable)
+	assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	now := time.Now()
+	breaker := newCircuitBreaker(1, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	breaker.Failure()
+	assert.False(t, breaker.Allow())
+
+	// Masa tunggu habis: hanya satu request percobaan yang diizinkan
+	now = now.Add(time.Minute)
+	assert.True(t, breaker.Allow())
+	assert.False(t, breaker.Allow())
+
+	breaker.Success()
+	assert.Equal(t, CircuitClosed, breaker.State())
+	assert.True(t, breaker.Allow())
+}
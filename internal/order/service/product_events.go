@@ -0,0 +1,77 @@
+package service
+
+import (
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/metrics"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// Routing key event perubahan produk yang dikirim product-service
+const (
+	ProductUpdatedRoutingKey = "product.updated"
+	ProductDeletedRoutingKey = "product.deleted"
+)
+
+// ErrInvalidProductEvent dikembalikan jika payload event produk tidak bisa dipakai
+var ErrInvalidProductEvent = errors.New("payload event produk tidak valid")
+
+// productChangedEvent adalah payload product.updated / product.deleted.
+// Field selain id bersifat opsional; pointer membedakan "tidak dikirim" dari nilai nol.
+type productChangedEvent struct {
+	ID    uuid.UUID `json:"id"`
+	Name  *string   `json:"name"`
+	Price *float64  `json:"price,string"`
+	Qty   *int      `json:"qty"`
+}
+
+// Invalidate membuang info produk dari cache sehingga request berikutnya
+// mengambil data terbaru dari product-service
+func (c *ProductClientImpl) Invalidate(productID uuid.UUID) {
+	c.cache.Delete(productID)
+}
+
+// UpdateCached mengganti info produk di cache dengan data dari event
+func (c *ProductClientImpl) UpdateCached(product *ProductResponse) {
+	c.cache.Set(product.ID, product)
+}
+
+// HandleProductEvent memperbarui cache info produk dari event product-service.
+// product.updated yang lengkap langsung menimpa cache; payload parsial atau
+// product.deleted cukup membuang entri agar diambil ulang saat dibutuhkan.
+func (c *ProductClientImpl) HandleProductEvent(ctx context.Context, routingKey string, body []byte) error {
+	var event productChangedEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return Permanent(fmt.Errorf("%w: %v", ErrInvalidProductEvent, err))
+	}
+	if event.ID == uuid.Nil {
+		return Permanent(fmt.Errorf("%w: id kosong", ErrInvalidProductEvent))
+	}
+
+	switch routingKey {
+	case ProductUpdatedRoutingKey:
+		if event.Name == nil || event.Price == nil || event.Qty == nil {
+			c.Invalidate(event.ID)
+			c.cfg.Logger.DebugContext(ctx, "CACHE EVICT", slog.String("cache", metrics.CacheProductInfo), logging.ProductID(event.ID))
+			return nil
+		}
+		c.UpdateCached(&ProductResponse{
+			ID:    event.ID,
+			Name:  *event.Name,
+			Price: *event.Price,
+			Qty:   *event.Qty,
+		})
+		c.cfg.Logger.DebugContext(ctx, "CACHE UPDATE", slog.String("cache", metrics.CacheProductInfo), logging.ProductID(event.ID))
+	case ProductDeletedRoutingKey:
+		c.Invalidate(event.ID)
+		c.cfg.Logger.DebugContext(ctx, "CACHE EVICT", slog.String("cache", metrics.CacheProductInfo), logging.ProductID(event.ID))
+	default:
+		c.cfg.Logger.WarnContext(ctx, "event produk diabaikan", slog.String("routing_key", routingKey))
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleProductEvent_UpdatedRefreshesCache(t *testing.T) {
+	client := NewProductClientImpl(ProductClientConfig{})
+	client.UpdateCached(&ProductResponse{ID: testProductID, Name: "Laptop", Price: 100, Qty: 10})
+
+	body := []byte(`{"id":"` + testProductID.String() + `","name":"Laptop Pro","price":"120.50","qty":3}`)
+	assert.NoError(t, client.HandleProductEvent(ctx, ProductUpdatedRoutingKey, body))
+
+	product, ok := client.cache.Get(testProductID)
+	assert.True(t, ok)
+	assert.Equal(t, "Laptop Pro", product.Name)
+	assert.Equal(t, 120.50, product.Price)
+	assert.Equal(t, 3, product.Qty)
+}
+
+func TestHandleProductEvent_PartialUpdateEvicts(t *testing.T) {
+	client := NewProductClientImpl(ProductClientConfig{})
+	client.UpdateCached(&ProductResponse{ID: testProductID, Name: "Laptop", Price: 100, Qty: 10})
+
+	body := []byte(`{"id":"` + testProductID.String() + `","qty":3}`)
+	assert.NoError(t, client.HandleProductEvent(ctx, ProductUpdatedRoutingKey, body))
+
+	_, ok := client.cache.Get(testProductID)
+	assert.False(t, ok)
+}
+
+func TestHandleProductEvent_DeletedEvicts(t *testing.T) {
+	client := NewProductClientImpl(ProductClientConfig{})
+	client.UpdateCached(&ProductResponse{ID: testProductID, Name: "Laptop", Price: 100, Qty: 10})
+
+	body := []byte(`{"id":"` + testProductID.String() + `"}`)
+	assert.NoError(t, client.HandleProductEvent(ctx, ProductDeletedRoutingKey, body))
+
+	_, ok := client.cache.Get(testProductID)
+	assert.False(t, ok)
+}
+
+func TestHandleProductEvent_InvalidPayload(t *testing.T) {
+	client := NewProductClientImpl(ProductClientConfig{})
+
+	assert.ErrorIs(t, client.HandleProductEvent(ctx, ProductDeletedRoutingKey, []byte(`not-json`)), ErrInvalidProductEvent)
+	assert.ErrorIs(t, client.HandleProductEvent(ctx, ProductDeletedRoutingKey, []byte(`{"id":"`+uuid.Nil.String()+`"}`)), ErrInvalidProductEvent)
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/metrics"
+	"challenge-order-service/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Error publish yang bisa diperiksa pemanggil dengan errors.Is
+var (
+	// ErrPublishNacked: broker menerima pesan tapi gagal menyimpannya (basic.nack)
+	ErrPublishNacked = errors.New("broker menolak pesan (nack)")
+	// ErrPublishUnroutable: tidak ada queue yang terikat ke exchange/routing key (basic.return)
+	ErrPublishUnroutable = errors.New("pesan tidak bisa dirutekan ke queue mana pun")
+	// ErrPublishTimeout: konfirmasi broker tidak datang dalam batas waktu
+	ErrPublishTimeout = errors.New("konfirmasi publish dari broker tidak diterima tepat waktu")
+	// ErrPublisherClosed: channel AMQP sudah tertutup
+	ErrPublisherClosed = errors.New("channel publisher sudah tertutup")
+)
+
+// DefaultConfirmTimeout adalah batas waktu menunggu ack/nack dari broker
+const DefaultConfirmTimeout = 5 * time.Second
+
+// AMQPChannel adalah bagian dari *amqp.Channel yang dipakai PublisherImpl
+type AMQPChannel interface {
+	Confirm(noWait bool) error
+	NotifyPublish(confirm chan amqp.Confirmation) chan amqp.Confirmation
+	NotifyReturn(c chan amqp.Return) chan amqp.Return
+	Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+	Close() error
+}
+
+// PublisherImpl mengimplementasikan Publisher (CONCRETE) dengan publisher confirms:
+// pesan dikirim persistent + mandatory, lalu Publish menunggu ack dari broker.
+type PublisherImpl struct {
+	ch             AMQPChannel
+	confirmTimeout time.Duration
+
+	mu       sync.Mutex // satu publish menunggu konfirmasinya sebelum publish berikutnya
+	confirms chan amqp.Confirmation
+	returns  chan amqp.Return
+	nextTag  uint64 // delivery tag yang diberikan broker untuk publish terakhir
+}
+
+// NewPublisherImpl mengaktifkan confirm mode pada channel.
+// confirmTimeout <= 0 memakai DefaultConfirmTimeout.
+func NewPublisherImpl(ch AMQPChannel, confirmTimeout time.Duration) (*PublisherImpl, error) {
+	if confirmTimeout <= 0 {
+		confirmTimeout = DefaultConfirmTimeout
+	}
+	if err := ch.Confirm(false); err != nil {
+		return nil, fmt.Errorf("gagal mengaktifkan publisher confirms: %w", err)
+	}
+
+	// Buffer menampung konfirmasi/return yang datang terlambat (setelah timeout)
+	// agar goroutine pembaca amqp tidak pernah terblokir.
+	return &PublisherImpl{
+		ch:             ch,
+		confirmTimeout: confirmTimeout,
+		confirms:       ch.NotifyPublish(make(chan amqp.Confirmation, 64)),
+		returns:        ch.NotifyReturn(make(chan amqp.Return, 64)),
+	}, nil
+}
+
+// Publish mengirim pesan lalu menunggu konfirmasi broker. Error yang mungkin:
+// ErrPublishNacked, ErrPublishUnroutable, ErrPublishTimeout, ErrPublisherClosed
+// atau ctx.Err() jika pemanggil berhenti menunggu.
+func (p *PublisherImpl) Publish(ctx context.Context, exchange, routingKey string, body []byte) error {
+	return p.PublishMessage(ctx, exchange, routingKey, amqp.Publishing{
+		ContentType: "application/json",
+		Body:        body,
+	})
+}
+
+// PublishMessage seperti Publish, tetapi pemanggil bisa mengatur header dan
+// properti pesan. DeliveryMode selalu persistent; MessageId diisi jika kosong.
+func (p *PublisherImpl) PublishMessage(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	ctx, span := tracing.Tracer().Start(ctx, publishSpanName(exchange),
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "rabbitmq"),
+			attribute.String("messaging.operation.type", "publish"),
+			attribute.String("messaging.destination.name", exchange),
+			attribute.String("messaging.rabbitmq.destination.routing_key", routingKey),
+		))
+	defer span.End()
+
+	// Trace context (dan request ID) ikut di header agar consumer melanjutkan
+	// trace yang sama. Header disalin supaya map milik pemanggil tidak berubah.
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		headers[logging.RequestIDHeader] = requestID
+	}
+	msg.Headers = tracing.InjectAMQP(ctx, headers)
+
+	start := time.Now()
+	err := p.publishMessage(ctx, exchange, routingKey, msg)
+	tracing.SetError(span, err)
+
+	metrics.Publishes.WithLabelValues(exchange, routingKey, publishResult(err)).Inc()
+	metrics.PublishDuration.WithLabelValues(exchange).Observe(time.Since(start).Seconds())
+	return err
+}
+
+// publishSpanName mengikuti konvensi "<tujuan> publish"; default exchange ditulis "(default)"
+func publishSpanName(exchange string) string {
+	if exchange == "" {
+		exchange = "(default)"
+	}
+	return exchange + " publish"
+}
+
+func (p *PublisherImpl) publishMessage(ctx context.Context, exchange, routingKey string, msg amqp.Publishing) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// 1. Kirim pesan (persistent + mandatory agar pesan tak terutekan dikembalikan)
+	if msg.MessageId == "" {
+		msg.MessageId = uuid.NewString()
+	}
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+	msg.DeliveryMode = amqp.Persistent
+	messageID := msg.MessageId
+
+	err := p.ch.Publish(
+		exchange,
+		routingKey,
+		true,  // mandatory
+		false, // immediate
+		msg,
+	)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPublisherClosed, err)
+	}
+	p.nextTag++
+	tag := p.nextTag
+
+	// 2. Tunggu ack/nack untuk delivery tag ini
+	timer := time.NewTimer(p.confirmTimeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case confirm, ok := <-p.confirms:
+			if !ok {
+				return ErrPublisherClosed
+			}
+			if confirm.DeliveryTag < tag {
+				// Konfirmasi terlambat milik publish sebelumnya yang sudah timeout
+				continue
+			}
+			if !confirm.Ack {
+				return fmt.Errorf("%w: %s/%s", ErrPublishNacked, exchange, routingKey)
+			}
+			// Broker mengirim basic.return sebelum ack, jadi cukup cek yang sudah masuk
+			if p.drainReturns(messageID) {
+				return fmt.Errorf("%w: %s/%s", ErrPublishUnroutable, exchange, routingKey)
+			}
+			return nil
+		case <-timer.C:
+			return fmt.Errorf("%w: %s/%s", ErrPublishTimeout, exchange, routingKey)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Close menutup channel AMQP milik publisher
+func (p *PublisherImpl) Close() error {
+	return p.ch.Close()
+}
+
+// publishResult adalah label "result" untuk metrik publish
+func publishResult(err error) string {
+	switch {
+	case err == nil:
+		return "success"
+	case errors.Is(err, ErrPublishNacked):
+		return "nacked"
+	case errors.Is(err, ErrPublishUnroutable):
+		return "unroutable"
+	case errors.Is(err, ErrPublishTimeout):
+		return "timeout"
+	case errors.Is(err, ErrPublisherClosed):
+		return "closed"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	default:
+		return "error"
+	}
+}
+
+// drainReturns mengosongkan pesan yang dikembalikan broker dan melaporkan
+// apakah salah satunya adalah messageID
+func (p *PublisherImpl) drainReturns(messageID string) bool {
+	returned := false
+	for {
+		select {
+		case ret, ok := <-p.returns:
+			if !ok {
+				return returned
+			}
+			if ret.MessageId == messageID {
+				returned = true
+			}
+		default:
+			return returned
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"challenge-order-service/internal/logging"
+)
+
+// ChannelOpener membuka channel AMQP baru (biasanya conn.Channel)
+type ChannelOpener func() (AMQPChannel, error)
+
+// DefaultPublisherPoolSize adalah jumlah channel publish jika ukuran tidak diatur
+const DefaultPublisherPoolSize = 4
+
+// PublisherPool mengimplementasikan Publisher di atas beberapa channel AMQP.
+// Setiap channel dipakai oleh satu goroutine pada satu waktu dan melacak
+// konfirmasinya sendiri, sehingga publish dari banyak request berjalan paralel.
+// Pool boleh dibuat sebelum broker terhubung: channel yang belum bisa dibuka
+// dibuka saat pertama dipakai atau saat Reset setelah koneksi tersambung.
+type PublisherPool struct {
+	open           ChannelOpener
+	confirmTimeout time.Duration
+	idle           chan *pooledPublisher
+	size           int
+	done           chan struct{} // ditutup oleh Shutdown; Publish berikutnya ditolak
+	shutdownOnce   sync.Once
+
+	// generation naik setiap Reset; channel dari generasi lama diganti saat kembali ke pool
+	generation atomic.Uint64
+}
+
+// pooledPublisher adalah satu channel di pool beserta generasinya.
+// PublisherImpl nil berarti channel belum terbuka (broker belum terhubung).
+type pooledPublisher struct {
+	*PublisherImpl
+	generation uint64
+}
+
+func (pp *pooledPublisher) ready() bool {
+	return pp.PublisherImpl != nil
+}
+
+// Close menutup channel jika sudah terbuka
+func (pp *pooledPublisher) Close() error {
+	if !pp.ready() {
+		return nil
+	}
+	return pp.PublisherImpl.Close()
+}
+
+// NewPublisherPool membuka size channel dalam confirm mode; channel yang gagal
+// dibuka (mis. broker belum terhubung) dicoba lagi saat dipakai.
+// size <= 0 memakai DefaultPublisherPoolSize.
+func NewPublisherPool(open ChannelOpener, size int, confirmTimeout time.Duration) *PublisherPool {
+	if size <= 0 {
+		size = DefaultPublisherPoolSize
+	}
+
+	pool := &PublisherPool{
+		open:           open,
+		confirmTimeout: confirmTimeout,
+		idle:           make(chan *pooledPublisher, size),
+		size:           size,
+		done:           make(chan struct{}),
+	}
+	for i := 0; i < size; i++ {
+		publisher, err := pool.newPublisher()
+		if err != nil {
+			publisher = &pooledPublisher{generation: pool.generation.Load()}
+		}
+		pool.idle <- publisher
+	}
+	return pool
+}
+
+func (p *PublisherPool) newPublisher() (*pooledPublisher, error) {
+	generation := p.generation.Load()
+	ch, err := p.open()
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuka channel publisher: %w", err)
+	}
+	publisher, err := NewPublisherImpl(ch, p.confirmTimeout)
+	if err != nil {
+		ch.Close()
+		return nil, err
+	}
+	return &pooledPublisher{PublisherImpl: publisher, generation: generation}, nil
+}
+
+// replace menutup publisher lama dan mengembalikan penggantinya.
+// Jika channel baru belum bisa dibuka (mis. broker masih mati), publisher lama dipertahankan.
+func (p *PublisherPool) replace(old *pooledPublisher) *pooledPublisher {
+	replacement, err := p.newPublisher()
+	if err != nil {
+		slog.Warn("gagal mengganti channel publisher", logging.Err(err))
+		return old
+	}
+	old.Close()
+	return replacement
+}
+
+// Reset menandai semua channel sebagai usang (mis. setelah reconnect ke broker).
+// Channel yang sedang tidak dipakai langsung diganti; sisanya diganti saat selesai dipakai.
+func (p *PublisherPool) Reset() {
+	p.generation.Add(1)
+	for i := 0; i < p.size; i++ {
+		select {
+		case publisher := <-p.idle:
+			if publisher.generation != p.generation.Load() {
+				publisher = p.replace(publisher)
+			}
+			p.idle <- publisher
+		default:
+			return
+		}
+	}
+}
+
+// Size mengembalikan jumlah channel di pool
+func (p *PublisherPool) Size() int {
+	return p.size
+}
+
+// Publish meminjam satu channel, mengirim pesan dan menunggu konfirmasinya.
+// Jika semua channel sedang dipakai, Publish menunggu sampai ada yang kosong atau ctx selesai.
+func (p *PublisherPool) Publish(ctx context.Context, exchange, routingKey string, body []byte) error {
+	var publisher *pooledPublisher
+	select {
+	case <-p.done:
+		return ErrPublisherClosed
+	default:
+	}
+	select {
+	case publisher = <-p.idle:
+	case <-p.done:
+		return ErrPublisherClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	// Channel belum terbuka: buka sekarang, atau laporkan broker belum tersedia
+	if !publisher.ready() {
+		fresh, err := p.newPublisher()
+		if err != nil {
+			p.idle <- publisher
+			return err
+		}
+		publisher = fresh
+	}
+
+	err := publisher.Publish(ctx, exchange, routingKey, body)
+	if errors.Is(err, ErrPublisherClosed) || publisher.generation != p.generation.Load() {
+		// Channel rusak (mis. ditutup broker) atau dari koneksi lama: ganti untuk publish berikutnya
+		publisher = p.replace(publisher)
+	}
+
+	p.idle <- publisher
+	return err
+}
+
+// Shutdown menolak publish baru, menunggu publish yang sedang berjalan menerima
+// konfirmasi broker, lalu menutup semua channel. Jika ctx selesai lebih dulu,
+// channel yang masih dipakai dibiarkan dan ctx.Err() dikembalikan.
+func (p *PublisherPool) Shutdown(ctx context.Context) error {
+	p.shutdownOnce.Do(func() { close(p.done) })
+
+	var firstErr error
+	for i := 0; i < p.size; i++ {
+		select {
+		case publisher := <-p.idle:
+			if err := publisher.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return firstErr
+}
+
+// Close menutup semua channel yang sedang tidak dipakai
+func (p *PublisherPool) Close() error {
+	var firstErr error
+	for {
+		select {
+		case publisher := <-p.idle:
+			if err := publisher.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		default:
+			return firstErr
+		}
+	}
+}
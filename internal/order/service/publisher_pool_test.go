@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+// latencyChannel meniru broker yang mengirim ack setelah jeda round-trip
+type latencyChannel struct {
+	latency time.Duration
+
+	mu       sync.Mutex
+	tag      uint64
+	closed   bool
+	confirms chan amqp.Confirmation
+}
+
+func (l *latencyChannel) Confirm(noWait bool) error { return nil }
+
+func (l *latencyChannel) NotifyPublish(c chan amqp.Confirmation) chan amqp.Confirmation {
+	l.confirms = c
+	return c
+}
+
+func (l *latencyChannel) NotifyReturn(c chan amqp.Return) chan amqp.Return { return c }
+
+func (l *latencyChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return errors.New("channel/connection is not open")
+	}
+	l.tag++
+	tag := l.tag
+	go func() {
+		time.Sleep(l.latency)
+		l.confirms <- amqp.Confirmation{DeliveryTag: tag, Ack: true}
+	}()
+	return nil
+}
+
+func (l *latencyChannel) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closed = true
+	return nil
+}
+
+func TestPublisherPool_PublishesInParallel(t *testing.T) {
+	const latency = 20 * time.Millisecond
+	pool := NewPublisherPool(func() (AMQPChannel, error) {
+		return &latencyChannel{latency: latency}, nil
+	}, 4, time.Second)
+	assert.Equal(t, 4, pool.Size())
+
+	// 4 publish bersamaan di 4 channel selesai kira-kira dalam satu round-trip
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, pool.Publish(ctx, "orders_exchange", "order.created", []byte(`{}`)))
+		}()
+	}
+	wg.Wait()
+	assert.Less(t, time.Since(start), 3*latency)
+}
+
+func TestPublisherPool_ReplacesClosedChannel(t *testing.T) {
+	var opened int32
+	var first *latencyChannel
+	pool := NewPublisherPool(func() (AMQPChannel, error) {
+		ch := &latencyChannel{}
+		if atomic.AddInt32(&opened, 1) == 1 {
+			first = ch
+		}
+		return ch, nil
+	}, 1, time.Second)
+
+	first.Close()
+	assert.ErrorIs(t, pool.Publish(ctx, "orders_exchange", "order.created", nil), ErrPublisherClosed)
+
+	// Channel pengganti dipakai untuk publish berikutnya
+	assert.NoError(t, pool.Publish(ctx, "orders_exchange", "order.created", nil))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&opened))
+}
+
+func TestPublisherPool_WaitsForIdleChannelUntilContextDone(t *testing.T) {
+	pool := NewPublisherPool(func() (AMQPChannel, error) {
+		return &latencyChannel{latency: time.Second}, nil
+	}, 1, 2*time.Second)
+
+	go pool.Publish(ctx, "orders_exchange", "order.created", nil)
+	time.Sleep(10 * time.Millisecond)
+
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pool.Publish(timeout, "orders_exchange", "order.created", nil), context.DeadlineExceeded)
+}
+
+func TestPublisherPool_OpensChannelsAfterBrokerConnects(t *testing.T) {
+	var connected atomic.Bool
+	pool := NewPublisherPool(func() (AMQPChannel, error) {
+		if !connected.Load() {
+			return nil, ErrBrokerNotConnected
+		}
+		return &latencyChannel{}, nil
+	}, 2, time.Second)
+	assert.Equal(t, 2, pool.Size(), "pool tetap dibuat walau broker belum terhubung")
+
+	// Selama broker belum terhubung, publish gagal tanpa memblokir
+	assert.ErrorIs(t, pool.Publish(ctx, "orders_exchange", "order.created", nil), ErrBrokerNotConnected)
+
+	// Setelah koneksi tersambung, channel dibuka saat dipakai
+	connected.Store(true)
+	assert.NoError(t, pool.Publish(ctx, "orders_exchange", "order.created", nil))
+	assert.NoError(t, pool.Shutdown(ctx))
+}
+
+func TestPublisherPool_ResetSwapsChannels(t *testing.T) {
+	var opened int32
+	pool := NewPublisherPool(func() (AMQPChannel, error) {
+		atomic.AddInt32(&opened, 1)
+		return &latencyChannel{}, nil
+	}, 2, time.Second)
+
+	// Setelah reconnect semua channel diganti dengan channel dari koneksi baru
+	pool.Reset()
+	assert.Equal(t, int32(4), atomic.LoadInt32(&opened))
+	assert.NoError(t, pool.Publish(ctx, "orders_exchange", "order.created", nil))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&opened))
+}
+
+func TestPublisherPool_ShutdownWaitsForInFlightConfirm(t *testing.T) {
+	const latency = 30 * time.Millisecond
+	pool := NewPublisherPool(func() (AMQPChannel, error) {
+		return &latencyChannel{latency: latency}, nil
+	}, 1, time.Second)
+
+	published := make(chan error, 1)
+	go func() { published <- pool.Publish(ctx, "orders_exchange", "order.created", nil) }()
+	time.Sleep(5 * time.Millisecond)
+
+	// Shutdown baru selesai setelah publish yang sedang berjalan dikonfirmasi
+	assert.NoError(t, pool.Shutdown(ctx))
+	assert.NoError(t, <-published)
+	assert.ErrorIs(t, pool.Publish(ctx, "orders_exchange", "order.created", nil), ErrPublisherClosed)
+}
+
+func TestPublisherPool_ShutdownDeadline(t *testing.T) {
+	pool := NewPublisherPool(func() (AMQPChannel, error) {
+		return &latencyChannel{latency: time.Second}, nil
+	}, 1, 2*time.Second)
+
+	go pool.Publish(ctx, "orders_exchange", "order.created", nil)
+	time.Sleep(5 * time.Millisecond)
+
+	timeout, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, pool.Shutdown(timeout), context.DeadlineExceeded)
+}
+
+// --- BENCHMARK: satu channel vs pool ---
+//
+//	go test ./internal/order/service -run '^$' -bench Publisher -cpu 8
+//
+// Broker disimulasikan dengan round-trip confirm 200µs. Dengan satu channel,
+// setiap publish menunggu ack publish sebelumnya; pool membagi beban ke N channel.
+
+const benchBrokerLatency = 200 * time.Microsecond
+
+func BenchmarkPublisher_SingleChannel(b *testing.B) {
+	publisher, _ := NewPublisherImpl(&latencyChannel{latency: benchBrokerLatency}, time.Second)
+	benchmarkPublisher(b, publisher)
+}
+
+func BenchmarkPublisherPool_4Channels(b *testing.B) {
+	benchmarkPublisherPool(b, 4)
+}
+
+func BenchmarkPublisherPool_16Channels(b *testing.B) {
+	benchmarkPublisherPool(b, 16)
+}
+
+func benchmarkPublisherPool(b *testing.B, size int) {
+	pool := NewPublisherPool(func() (AMQPChannel, error) {
+		return &latencyChannel{latency: benchBrokerLatency}, nil
+	}, size, time.Second)
+	benchmarkPublisher(b, pool)
+}
+
+func benchmarkPublisher(b *testing.B, publisher Publisher) {
+	body := []byte(`{"orderId":"bench"}`)
+	b.SetParallelism(4)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := publisher.Publish(ctx, "orders_exchange", "order.created", body); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeConfirmChannel meniru *amqp.Channel dalam confirm mode.
+// respond menentukan reaksi broker untuk setiap publish.
+type fakeConfirmChannel struct {
+	confirms  chan amqp.Confirmation
+	returns   chan amqp.Return
+	published []amqp.Publishing
+	tag       uint64
+	respond   func(ch *fakeConfirmChannel, msg amqp.Publishing)
+}
+
+func (f *fakeConfirmChannel) Confirm(noWait bool) error { return nil }
+
+func (f *fakeConfirmChannel) Close() error { return nil }
+
+func (f *fakeConfirmChannel) NotifyPublish(c chan amqp.Confirmation) chan amqp.Confirmation {
+	f.confirms = c
+	return c
+}
+
+func (f *fakeConfirmChannel) NotifyReturn(c chan amqp.Return) chan amqp.Return {
+	f.returns = c
+	return c
+}
+
+func (f *fakeConfirmChannel) Publish(exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	f.tag++
+	f.published = append(f.published, msg)
+	if f.respond != nil {
+		f.respond(f, msg)
+	}
+	return nil
+}
+
+func ack(f *fakeConfirmChannel, _ amqp.Publishing) {
+	f.confirms <- amqp.Confirmation{DeliveryTag: f.tag, Ack: true}
+}
+
+func TestPublisher_AckedMessageIsPersistentAndMandatory(t *testing.T) {
+	fake := &fakeConfirmChannel{respond: ack}
+	publisher, err := NewPublisherImpl(fake, time.Second)
+	assert.NoError(t, err)
+
+	assert.NoError(t, publisher.Publish(ctx, "orders_exchange", "order.created", []byte(`{}`)))
+	assert.Equal(t, amqp.Persistent, fake.published[0].DeliveryMode)
+	assert.NotEmpty(t, fake.published[0].MessageId)
+}
+
+func TestPublisher_Nack(t *testing.T) {
+	fake := &fakeConfirmChannel{respond: func(f *fakeConfirmChannel, _ amqp.Publishing) {
+		f.confirms <- amqp.Confirmation{DeliveryTag: f.tag, Ack: false}
+	}}
+	publisher, _ := NewPublisherImpl(fake, time.Second)
+
+	err := publisher.Publish(ctx, "orders_exchange", "order.created", []byte(`{}`))
+	assert.ErrorIs(t, err, ErrPublishNacked)
+}
+
+func TestPublisher_UnroutableMessageIsReturned(t *testing.T) {
+	fake := &fakeConfirmChannel{respond: func(f *fakeConfirmChannel, msg amqp.Publishing) {
+		f.returns <- amqp.Return{MessageId: msg.MessageId, ReplyText: "NO_ROUTE"}
+		f.confirms <- amqp.Confirmation{DeliveryTag: f.tag, Ack: true}
+	}}
+	publisher, _ := NewPublisherImpl(fake, time.Second)
+
+	err := publisher.Publish(ctx, "orders_exchange", "order.unknown", []byte(`{}`))
+	assert.ErrorIs(t, err, ErrPublishUnroutable)
+}
+
+func TestPublisher_TimeoutThenLateConfirmIsSkipped(t *testing.T) {
+	fake := &fakeConfirmChannel{}
+	publisher, _ := NewPublisherImpl(fake, 10*time.Millisecond)
+
+	// Publish pertama tidak pernah dikonfirmasi tepat waktu
+	err := publisher.Publish(ctx, "orders_exchange", "order.created", []byte(`{}`))
+	assert.ErrorIs(t, err, ErrPublishTimeout)
+
+	// Ack terlambat untuk tag 1 datang bersamaan dengan ack untuk tag 2
+	fake.respond = func(f *fakeConfirmChannel, _ amqp.Publishing) {
+		f.confirms <- amqp.Confirmation{DeliveryTag: 1, Ack: false}
+		f.confirms <- amqp.Confirmation{DeliveryTag: f.tag, Ack: true}
+	}
+	assert.NoError(t, publisher.Publish(ctx, "orders_exchange", "order.created", []byte(`{}`)))
+}
+
+func TestPublisher_ConfirmModeFailure(t *testing.T) {
+	_, err := NewPublisherImpl(&failingConfirmChannel{}, time.Second)
+	assert.Error(t, err)
+}
+
+func TestPublisher_CancelledContext(t *testing.T) {
+	fake := &fakeConfirmChannel{respond: ack}
+	publisher, _ := NewPublisherImpl(fake, time.Second)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.ErrorIs(t, publisher.Publish(cancelled, "orders_exchange", "order.created", nil), context.Canceled)
+	assert.Empty(t, fake.published)
+}
+
+type failingConfirmChannel struct{ fakeConfirmChannel }
+
+func (f *failingConfirmChannel) Confirm(noWait bool) error { return errors.New("not supported") }
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"challenge-order-service/internal/logging"
+	"challenge-order-service/internal/order"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// Routing key hasil reservasi stok dari product-service dan event final order
+const (
+	StockReservedRoutingKey  = "stock.reserved"
+	StockRejectedRoutingKey  = "stock.rejected"
+	OrderProcessedRoutingKey = "order.processed"
+	OrderFailedRoutingKey    = "order.failed"
+)
+
+// ErrInvalidStockEvent dikembalikan jika payload event stok tidak bisa dipakai
+var ErrInvalidStockEvent = errors.New("payload event stok tidak valid")
+
+// stockResultEvent adalah payload stock.reserved / stock.rejected
+type stockResultEvent struct {
+	OrderID uuid.UUID `json:"orderId"`
+	Reason  string    `json:"reason"`
+}
+
+// StockEventHandler menutup siklus order: hasil reservasi stok dari
+// product-service memindahkan order PENDING ke PROCESSED atau FAILED.
+type StockEventHandler struct {
+	svc    OrderService
+	logger *slog.Logger
+}
+
+// NewStockEventHandler membuat StockEventHandler
+func NewStockEventHandler(svc OrderService) *StockEventHandler {
+	return &StockEventHandler{svc: svc, logger: slog.Default()}
+}
+
+// SetLogger mengganti logger handler (default slog.Default())
+func (h *StockEventHandler) SetLogger(logger *slog.Logger) {
+	h.logger = logger
+}
+
+// Handle memproses satu event stok. Event duplikat untuk order yang sudah
+// final diabaikan agar redelivery aman.
+func (h *StockEventHandler) Handle(ctx context.Context, routingKey string, body []byte) error {
+	// 1. Tentukan status akhir dari routing key
+	var status order.OrderStatus
+	switch routingKey {
+	case StockReservedRoutingKey:
+		status = order.StatusProcessed
+	case StockRejectedRoutingKey:
+		status = order.StatusFailed
+	default:
+		h.logger.WarnContext(ctx, "event stok diabaikan", slog.String("routing_key", routingKey))
+		return nil
+	}
+
+	// 2. Decode payload
+	var event stockResultEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return Permanent(fmt.Errorf("%w: %v", ErrInvalidStockEvent, err))
+	}
+	if event.OrderID == uuid.Nil {
+		return Permanent(fmt.Errorf("%w: orderId kosong", ErrInvalidStockEvent))
+	}
+
+	// 3. Finalisasi order
+	_, err := h.svc.FinalizeOrder(ctx, event.OrderID, status, event.Reason)
+	if errors.Is(err, order.ErrInvalidStatusTransition) {
+		h.logger.InfoContext(ctx, "event stok diabaikan: order sudah final",
+			slog.String("routing_key", routingKey), logging.OrderID(event.OrderID))
+		return nil
+	}
+	return err
+}
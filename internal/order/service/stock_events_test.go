@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"challenge-order-service/internal/order"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStockEventHandler_ReservedProcessesOrder(t *testing.T) {
+	svc, mockRepo, mr, _ := setupTest(t)
+	defer mr.Close()
+	handler := NewStockEventHandler(svc)
+
+	mockRepo.On("UpdateStatus", mock.Anything, testOrderID, order.StatusProcessed).
+		Return(&order.Order{ID: testOrderID, ProductID: testProductID, Status: order.StatusProcessed}, order.StatusPending, nil).Once()
+
+	err := handler.Handle(ctx, StockReservedRoutingKey, []byte(`{"orderId":"`+testOrderID.String()+`"}`))
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	if assert.Len(t, mockRepo.StatusEvents, 1) {
+		assert.Equal(t, OrderProcessedRoutingKey, mockRepo.StatusEvents[0].RoutingKey)
+	}
+}
+
+func TestStockEventHandler_DuplicateEventIsIgnored(t *testing.T) {
+	svc, mockRepo, mr, _ := setupTest(t)
+	defer mr.Close()
+	handler := NewStockEventHandler(svc)
+
+	// Order sudah FAILED sebelumnya: redelivery tidak boleh dianggap error
+	mockRepo.On("UpdateStatus", mock.Anything, testOrderID, order.StatusFailed).
+		Return(nil, order.OrderStatus(""), order.ErrInvalidStatusTransition).Once()
+
+	err := handler.Handle(ctx, StockRejectedRoutingKey, []byte(`{"orderId":"`+testOrderID.String()+`","reason":"stok habis"}`))
+
+	assert.NoError(t, err)
+	assert.Empty(t, mockRepo.StatusEvents)
+}
+
+func TestStockEventHandler_InvalidPayload(t *testing.T) {
+	svc, mockRepo, mr, _ := setupTest(t)
+	defer mr.Close()
+	handler := NewStockEventHandler(svc)
+
+	assert.ErrorIs(t, handler.Handle(ctx, StockReservedRoutingKey, []byte(`{}`)), ErrInvalidStockEvent)
+	assert.ErrorIs(t, handler.Handle(ctx, StockReservedRoutingKey, []byte(`not-json`)), ErrInvalidStockEvent)
+	mockRepo.AssertNotCalled(t, "UpdateStatus", mock.Anything, mock.Anything, mock.Anything)
+}
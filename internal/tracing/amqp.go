@@ -0,0 +1,66 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/streadway/amqp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// AMQPHeadersCarrier membuat amqp.Table bisa dipakai propagator OpenTelemetry
+type AMQPHeadersCarrier amqp.Table
+
+// Get mengembalikan nilai header sebagai string (kosong jika bukan string)
+func (c AMQPHeadersCarrier) Get(key string) string {
+	value, _ := c[key].(string)
+	return value
+}
+
+// Set menulis header
+func (c AMQPHeadersCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys mengembalikan semua nama header
+func (c AMQPHeadersCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// InjectAMQP menulis trace context dari ctx (traceparent, tracestate, baggage)
+// ke header pesan. headers nil dibuatkan baru.
+func InjectAMQP(ctx context.Context, headers amqp.Table) amqp.Table {
+	if headers == nil {
+		headers = amqp.Table{}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, AMQPHeadersCarrier(headers))
+	return headers
+}
+
+// ExtractAMQP membaca trace context dari header pesan ke ctx
+func ExtractAMQP(ctx context.Context, headers amqp.Table) context.Context {
+	if headers == nil {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, AMQPHeadersCarrier(headers))
+}
+
+// InjectMap menulis trace context dari ctx ke map baru, mis. untuk disimpan
+// bersama baris outbox dan dipulihkan saat event di-publish belakangan
+func InjectMap(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// ExtractMap membaca trace context dari map hasil InjectMap ke ctx
+func ExtractMap(ctx context.Context, headers map[string]string) context.Context {
+	if len(headers) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headers))
+}
@@ -0,0 +1,152 @@
+// Package tracing menyiapkan OpenTelemetry (provider, exporter, propagator)
+// beserta instrumentasi untuk GORM, Redis dan header pesan AMQP.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName dipakai sebagai nama tracer untuk span buatan service ini
+const InstrumentationName = "challenge-order-service"
+
+// Exporter yang didukung Config.Exporter
+const (
+	ExporterNone   = "none"   // tracing nonaktif (span tetap dibuat tapi dibuang)
+	ExporterOTLP   = "otlp"   // OTLP/HTTP; endpoint dari Config.OTLPEndpoint atau OTEL_EXPORTER_OTLP_ENDPOINT
+	ExporterStdout = "stdout" // JSON ke stdout, untuk debugging lokal
+	ExporterFile   = "file"   // JSON ke Config.FilePath
+)
+
+// Config mengatur exporter dan sampling tracing
+type Config struct {
+	ServiceName  string
+	Exporter     string
+	FilePath     string  // dipakai jika Exporter = "file"
+	OTLPEndpoint string  // dipakai jika Exporter = "otlp"; kosong = dari env OTEL_EXPORTER_OTLP_*
+	SampleRatio  float64 // 0..1, dipakai untuk trace baru (root); child mengikuti parent
+}
+
+// DefaultConfig adalah konfigurasi yang dipakai untuk nilai yang kosong
+func DefaultConfig() Config {
+	return Config{
+		ServiceName: "order-service",
+		Exporter:    ExporterNone,
+		FilePath:    "traces.json",
+		SampleRatio: 1,
+	}
+}
+
+// Setup memasang TracerProvider dan propagator W3C (traceparent + baggage) global.
+// Fungsi yang dikembalikan mengirim span tersisa lalu menghentikan provider.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	def := DefaultConfig()
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = def.ServiceName
+	}
+	if cfg.Exporter == "" {
+		cfg.Exporter = def.Exporter
+	}
+	if cfg.FilePath == "" {
+		cfg.FilePath = def.FilePath
+	}
+	if cfg.SampleRatio <= 0 || cfg.SampleRatio > 1 {
+		cfg.SampleRatio = def.SampleRatio
+	}
+
+	// Propagator dipasang walau exporter nonaktif, agar trace dari hulu tetap diteruskan
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	exporter, closeExporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat resource tracing: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeExporter != nil {
+			closeExporter()
+		}
+		return err
+	}, nil
+}
+
+// newExporter membuat exporter sesuai cfg.Exporter; nil untuk "none"
+func newExporter(ctx context.Context, cfg Config) (sdktrace.SpanExporter, func(), error) {
+	switch cfg.Exporter {
+	case ExporterNone:
+		return nil, nil, nil
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.OTLPEndpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+		}
+		exporter, err := otlptracehttp.New(ctx, opts...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gagal membuat exporter OTLP: %w", err)
+		}
+		return exporter, nil, nil
+	case ExporterStdout:
+		exporter, err := stdouttrace.New()
+		return exporter, nil, err
+	case ExporterFile:
+		f, err := os.OpenFile(cfg.FilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, fmt.Errorf("gagal membuka file trace: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return exporter, func() { f.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("exporter tracing tidak dikenal: %q", cfg.Exporter)
+	}
+}
+
+// Tracer mengembalikan tracer untuk span buatan service ini
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// SetError menandai span gagal dengan err (tidak melakukan apa-apa jika err nil)
+func SetError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}